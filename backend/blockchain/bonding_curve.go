@@ -2,113 +2,120 @@ package blockchain
 
 import (
 	"math"
-)
-
-// CurveType defines the type of bonding curve
-type CurveType string
 
-const (
-	CurveTypeExponential     CurveType = "exponential"
-	CurveTypeLinear          CurveType = "linear"
-	CurveTypeConstantProduct CurveType = "constant_product"
+	"memepump/units"
 )
 
-// BondingCurve calculates prices based on supply
+// BondingCurve prices a coin's curve in integer base units and tracks graduation.
+// The Curve does the math in whole tokens and SOL; conversions round in the platform's favour.
 type BondingCurve struct {
-	CurveType  CurveType
-	BasePrice  float64 // Starting price
-	K          float64 // Steepness for exponential, reserve ratio for constant product
-	Slope      float64 // Slope for linear curves
-	MaxSupply  float64 // Maximum supply (for graduation)
-	TargetMcap float64 // Target market cap for graduation
+	Curve      Curve
+	MaxSupply  units.Tokens   // Maximum supply (for graduation)
+	TargetMcap units.Lamports // Target market cap for graduation
 }
 
+// Defaults for the pump.fun style curve
+var (
+	DefaultBasePrice  = units.PriceFromSol(0.00001)                   // Starting at 0.00001 SOL
+	DefaultK          = 0.000000003                                   // Steepness factor (price grows ~20x over the full supply)
+	DefaultMaxSupply  = units.Tokens(1000000000 * units.TokenUnit)    // 1 billion tokens
+	DefaultTargetMcap = units.Lamports(100000 * units.LamportsPerSol) // Graduate at 100k SOL market cap
+)
+
 // DefaultCurve returns the pump.fun style default curve
 func DefaultCurve() *BondingCurve {
-	return &BondingCurve{
-		CurveType:  CurveTypeExponential,
-		BasePrice:  0.00001,    // Starting at 0.00001 SOL
-		K:          0.000001,   // Steepness factor
-		MaxSupply:  1000000000, // 1 billion tokens
-		TargetMcap: 100000,     // Graduate at $100k market cap
+	bc, err := NewFromParams(CurveSpec{}, 0)
+	if err != nil {
+		panic("blockchain: invalid default curve: " + err.Error())
 	}
+	return bc
+}
+
+// CurveType returns the registered type of the underlying curve
+func (bc *BondingCurve) CurveType() CurveType {
+	return bc.Curve.Spec().Type
 }
 
 // CalculatePrice returns the current price based on supply
-func (bc *BondingCurve) CalculatePrice(supply float64) float64 {
-	switch bc.CurveType {
-	case CurveTypeExponential:
-		// Price = basePrice * e^(k * supply)
-		// More aggressive growth as supply increases
-		return bc.BasePrice * math.Exp(bc.K*supply)
-
-	case CurveTypeLinear:
-		// Price = basePrice + (slope * supply)
-		// Predictable, steady price increase
-		return bc.BasePrice + (bc.Slope * supply)
-
-	case CurveTypeConstantProduct:
-		// Uniswap-style: x * y = k
-		// Price = k / (maxSupply - supply)
-		remaining := bc.MaxSupply - supply
-		if remaining <= 0 {
-			remaining = 1 // Prevent division by zero
-		}
-		return bc.K / remaining
+func (bc *BondingCurve) CalculatePrice(supply units.Tokens) units.Price {
+	return units.PriceFromSol(bc.Curve.Price(supply.Float()))
+}
 
-	default:
-		// Fallback to simple quadratic (original memepump formula)
-		return math.Pow(supply, 2) / 1000000
+// CalculateBuyPrice returns the cost to buy a specific amount, rounded up to the lamport.
+// Costs that cannot be paid, such as buying out a constant product curve, return units.MaxLamports.
+func (bc *BondingCurve) CalculateBuyPrice(currentSupply, buyAmount units.Tokens) units.Lamports {
+	if buyAmount <= 0 {
+		return 0
 	}
+	return units.LamportsCeil(bc.Curve.Integral(currentSupply.Float(), buyAmount.Float()))
 }
 
-// CalculateBuyPrice returns the cost to buy a specific amount
-func (bc *BondingCurve) CalculateBuyPrice(currentSupply, buyAmount float64) float64 {
-	// For accurate pricing, we integrate the curve
-	// Using numerical approximation with small steps
-	steps := 100
-	stepSize := buyAmount / float64(steps)
-	totalCost := 0.0
+// CalculateSellReturn returns the amount received for selling, rounded down to the lamport
+func (bc *BondingCurve) CalculateSellReturn(currentSupply, sellAmount units.Tokens) units.Lamports {
+	if sellAmount <= 0 {
+		return 0
+	}
+	if sellAmount > currentSupply {
+		sellAmount = currentSupply
+	}
+	return units.LamportsFloor(bc.Curve.Integral((currentSupply - sellAmount).Float(), sellAmount.Float()))
+}
 
-	for i := 0; i < steps; i++ {
-		supplyAtStep := currentSupply + (float64(i) * stepSize)
-		priceAtStep := bc.CalculatePrice(supplyAtStep)
-		totalCost += priceAtStep * stepSize
+// CalculateTokensForSol returns how many tokens solAmount buys from currentSupply,
+// capped at the remaining curve supply. The result never costs more than solAmount.
+func (bc *BondingCurve) CalculateTokensForSol(currentSupply units.Tokens, solAmount units.Lamports) units.Tokens {
+	remaining := bc.MaxSupply - currentSupply
+	if solAmount <= 0 || remaining <= 0 {
+		return 0
 	}
 
-	return totalCost
-}
+	whole := bc.Curve.Inverse(currentSupply.Float(), solAmount.SOL())
+	if math.IsNaN(whole) || whole <= 0 {
+		return 0
+	}
+
+	tokens := units.TokensFromFloat(whole)
+	if tokens > remaining {
+		tokens = remaining
+	}
 
-// CalculateSellReturn returns the amount received for selling
-func (bc *BondingCurve) CalculateSellReturn(currentSupply, sellAmount float64) float64 {
-	// Reverse of buy calculation
-	steps := 100
-	stepSize := sellAmount / float64(steps)
-	totalReturn := 0.0
-
-	for i := 0; i < steps; i++ {
-		supplyAtStep := currentSupply - (float64(i) * stepSize)
-		if supplyAtStep < 0 {
-			supplyAtStep = 0
+	// Rounding the cost up can leave the floor a few base units too expensive
+	for tokens > 0 && bc.CalculateBuyPrice(currentSupply, tokens) > solAmount {
+		step := tokens / 1000000000000
+		if step < 1 {
+			step = 1
 		}
-		priceAtStep := bc.CalculatePrice(supplyAtStep)
-		totalReturn += priceAtStep * stepSize
+		tokens -= step
 	}
 
-	return totalReturn
+	return tokens
 }
 
 // CalculateMarketCap returns market cap based on supply and price
-func (bc *BondingCurve) CalculateMarketCap(supply float64) float64 {
-	return supply * bc.CalculatePrice(supply)
+func (bc *BondingCurve) CalculateMarketCap(supply units.Tokens) units.Lamports {
+	return units.Value(supply, bc.CalculatePrice(supply))
 }
 
-// CalculateProgress returns progress towards graduation (0-100)
-func (bc *BondingCurve) CalculateProgress(marketCap float64) float64 {
-	if bc.TargetMcap <= 0 {
-		return 0
+// VirtualReserves returns the virtual SOL and token reserves at supply.
+// ok is false for curves that are not backed by reserves.
+func (bc *BondingCurve) VirtualReserves(supply units.Tokens) (sol units.Lamports, tokens units.Tokens, ok bool) {
+	rc, ok := bc.Curve.(ReserveCurve)
+	if !ok {
+		return 0, 0, false
+	}
+	solF, tokensF := rc.Reserves(supply.Float())
+	return units.LamportsFromSol(solF), units.TokensFromFloat(tokensF), true
+}
+
+// CalculateProgress returns progress towards graduation (0-100).
+// Reserve curves measure the share of real tokens sold, the others the market cap target.
+func (bc *BondingCurve) CalculateProgress(supply units.Tokens, marketCap units.Lamports) float64 {
+	progress := 0.0
+	if _, reserves := bc.Curve.(ReserveCurve); reserves {
+		progress = float64(supply) / float64(bc.MaxSupply) * 100
+	} else if bc.TargetMcap > 0 {
+		progress = float64(marketCap) / float64(bc.TargetMcap) * 100
 	}
-	progress := (marketCap / bc.TargetMcap) * 100
 	if progress > 100 {
 		progress = 100
 	}
@@ -116,25 +123,34 @@ func (bc *BondingCurve) CalculateProgress(marketCap float64) float64 {
 }
 
 // ShouldGraduate returns true if the token should graduate to DEX
-func (bc *BondingCurve) ShouldGraduate(marketCap float64) bool {
+func (bc *BondingCurve) ShouldGraduate(supply units.Tokens, marketCap units.Lamports) bool {
+	if supply >= bc.MaxSupply {
+		return true
+	}
+	if _, reserves := bc.Curve.(ReserveCurve); reserves {
+		return false
+	}
 	return marketCap >= bc.TargetMcap
 }
 
 // GetCurveDataPoints returns points for visualization (frontend chart)
-func (bc *BondingCurve) GetCurveDataPoints(currentSupply float64, numPoints int) []CurvePoint {
+func (bc *BondingCurve) GetCurveDataPoints(currentSupply units.Tokens, numPoints int) []CurvePoint {
 	points := make([]CurvePoint, numPoints)
-	maxPlotSupply := bc.MaxSupply * 1.2 // Show 20% beyond max
+	maxPlotSupply := bc.MaxSupply + bc.MaxSupply/5 // Show 20% beyond max
+	if _, reserves := bc.Curve.(ReserveCurve); reserves {
+		maxPlotSupply = bc.MaxSupply // Virtual reserves may run out just past the real ones
+	}
+	step := maxPlotSupply / units.Tokens(numPoints-1)
 
 	for i := 0; i < numPoints; i++ {
-		supply := (float64(i) / float64(numPoints-1)) * maxPlotSupply
+		supply := step * units.Tokens(i)
 		price := bc.CalculatePrice(supply)
-		mcap := supply * price
 
 		points[i] = CurvePoint{
 			Supply:    supply,
 			Price:     price,
-			MarketCap: mcap,
-			IsCurrent: supply <= currentSupply && (supply+maxPlotSupply/float64(numPoints)) > currentSupply,
+			MarketCap: units.Value(supply, price),
+			IsCurrent: supply <= currentSupply && supply+step > currentSupply,
 		}
 	}
 
@@ -143,35 +159,45 @@ func (bc *BondingCurve) GetCurveDataPoints(currentSupply float64, numPoints int)
 
 // CurvePoint represents a single point on the curve for visualization
 type CurvePoint struct {
-	Supply    float64 `json:"supply"`
-	Price     float64 `json:"price"`
-	MarketCap float64 `json:"marketCap"`
-	IsCurrent bool    `json:"isCurrent"`
+	Supply    units.Tokens   `json:"supply"`
+	Price     units.Price    `json:"price"`
+	MarketCap units.Lamports `json:"marketCap"`
+	IsCurrent bool           `json:"isCurrent"`
 }
 
-// NewFromParams creates a BondingCurve from stored parameters
-func NewFromParams(curveType string, k, slope, basePrice, maxSupply, targetMcap float64) *BondingCurve {
-	ct := CurveType(curveType)
-	if ct != CurveTypeExponential && ct != CurveTypeLinear && ct != CurveTypeConstantProduct {
-		ct = CurveTypeExponential
+// NewFromParams creates a BondingCurve from stored parameters.
+// Zero values fall back to the defaults; the result is validated by the curve's factory.
+func NewFromParams(spec CurveSpec, targetMcap units.Lamports) (*BondingCurve, error) {
+	if spec.Type == "" {
+		spec.Type = CurveTypeExponential
 	}
-
-	if basePrice <= 0 {
-		basePrice = 0.00001
+	if spec.Type == CurveTypeExponential && spec.K == 0 {
+		spec.K = DefaultK
+	}
+	if spec.BasePrice == 0 {
+		spec.BasePrice = DefaultBasePrice
+	}
+	if spec.MaxSupply == 0 {
+		spec.MaxSupply = DefaultMaxSupply
+		if spec.Type == CurveTypeVirtualAMM {
+			spec.MaxSupply = DefaultRealTokenReserves
+		}
+	}
+	if targetMcap == 0 {
+		targetMcap = DefaultTargetMcap
 	}
-	if maxSupply <= 0 {
-		maxSupply = 1000000000
+	if targetMcap < 0 {
+		return nil, invalidCurve("targetMcap must be positive")
 	}
-	if targetMcap <= 0 {
-		targetMcap = 100000
+
+	curve, err := NewCurve(spec)
+	if err != nil {
+		return nil, err
 	}
 
 	return &BondingCurve{
-		CurveType:  ct,
-		BasePrice:  basePrice,
-		K:          k,
-		Slope:      slope,
-		MaxSupply:  maxSupply,
+		Curve:      curve,
+		MaxSupply:  spec.MaxSupply,
 		TargetMcap: targetMcap,
-	}
+	}, nil
 }
@@ -0,0 +1,168 @@
+package blockchain
+
+import (
+	"encoding/json"
+	"math"
+	"testing"
+	"testing/quick"
+
+	"memepump/units"
+)
+
+func testCurves() map[string]*BondingCurve {
+	specs := map[string]CurveSpec{
+		"exponential":      {},
+		"flat":             {Type: CurveTypeLinear, BasePrice: units.PriceFromSol(0.0001)},
+		"linear":           {Type: CurveTypeLinear, BasePrice: units.PriceFromSol(0.00001), Slope: 0.0000000002},
+		"constant_product": {Type: CurveTypeConstantProduct, K: 1000},
+		"sigmoid": {Type: CurveTypeSigmoid, Params: json.RawMessage(
+			`{"maxPrice":"1000000000000","midpoint":"500000000000000","steepness":0.00000001}`)},
+		"logarithmic": {Type: CurveTypeLogarithmic, Params: json.RawMessage(
+			`{"scale":0.0001,"offset":"10000000000000"}`)},
+		"virtual_amm": {Type: CurveTypeVirtualAMM},
+		"piecewise": {Type: CurveTypePiecewise, Params: json.RawMessage(`{"segments":[
+			{"from":"0","price":"10000000000","slope":0},
+			{"from":"200000000000000","price":"20000000000","slope":0.0000000000001},
+			{"from":"600000000000000","price":"100000000000","slope":0}]}`)},
+	}
+
+	curves := make(map[string]*BondingCurve, len(specs))
+	for name, spec := range specs {
+		bc, err := NewFromParams(spec, 0)
+		if err != nil {
+			panic(name + ": " + err.Error())
+		}
+		curves[name] = bc
+	}
+	return curves
+}
+
+// position maps two random fractions onto a supply and a non-zero amount within the curve
+func position(bc *BondingCurve, supplyFrac, amountFrac uint32) (supply, amount units.Tokens) {
+	max := bc.MaxSupply / 100 * 99
+	supply = units.Tokens(units.MulDiv(int64(max), int64(supplyFrac), math.MaxUint32))
+	amount = units.Tokens(units.MulDiv(int64(max-supply), int64(amountFrac), math.MaxUint32)) + 1
+	return supply, amount
+}
+
+func TestBuyThenSellNeverCreatesValue(t *testing.T) {
+	for name, bc := range testCurves() {
+		property := func(supplyFrac, amountFrac uint32) bool {
+			supply, amount := position(bc, supplyFrac, amountFrac)
+			cost := bc.CalculateBuyPrice(supply, amount)
+			proceeds := bc.CalculateSellReturn(supply+amount, amount)
+			return proceeds <= cost
+		}
+		if err := quick.Check(property, nil); err != nil {
+			t.Errorf("%s: %v", name, err)
+		}
+	}
+}
+
+func TestSplitBuysCostTheSame(t *testing.T) {
+	for name, bc := range testCurves() {
+		property := func(supplyFrac, amountFrac uint32) bool {
+			supply, amount := position(bc, supplyFrac, amountFrac)
+			half := amount / 2
+			whole := bc.CalculateBuyPrice(supply, amount)
+			split := bc.CalculateBuyPrice(supply, half) + bc.CalculateBuyPrice(supply+half, amount-half)
+			// Each leg rounds up by at most one lamport
+			return math.Abs(float64(whole-split)) <= 2+float64(whole)*1e-9
+		}
+		if err := quick.Check(property, nil); err != nil {
+			t.Errorf("%s: %v", name, err)
+		}
+	}
+}
+
+func TestIntegralMatchesNumericalIntegration(t *testing.T) {
+	for name, bc := range testCurves() {
+		supply, amount := bc.MaxSupply.Float()*0.3, bc.MaxSupply.Float()*0.5
+
+		// Midpoint rule with many steps as an independent reference
+		steps := 100000
+		step := amount / float64(steps)
+		reference := 0.0
+		for i := 0; i < steps; i++ {
+			reference += bc.Curve.Price(supply+(float64(i)+0.5)*step) * step
+		}
+
+		got := bc.CalculateBuyPrice(units.TokensFromFloat(supply), units.TokensFromFloat(amount)).SOL()
+		if math.Abs(got-reference) > reference*1e-6 {
+			t.Errorf("%s: CalculateBuyPrice = %g SOL; want %g", name, got, reference)
+		}
+	}
+}
+
+func TestTokensForSolInvertsBuyPrice(t *testing.T) {
+	for name, bc := range testCurves() {
+		property := func(supplyFrac uint32, lamports uint32) bool {
+			supply, _ := position(bc, supplyFrac, 0)
+			sol := units.Lamports(lamports)*1000 + 1
+
+			tokens := bc.CalculateTokensForSol(supply, sol)
+			if bc.CalculateBuyPrice(supply, tokens) > sol {
+				return false
+			}
+			// Either a slightly larger buy is unaffordable or the curve ran out of supply
+			more := tokens + tokens/1000000000 + 2
+			return more > bc.MaxSupply-supply || bc.CalculateBuyPrice(supply, more) > sol
+		}
+		if err := quick.Check(property, nil); err != nil {
+			t.Errorf("%s: %v", name, err)
+		}
+	}
+}
+
+func TestConstantProductCannotBuyOutSupply(t *testing.T) {
+	bc := testCurves()["constant_product"]
+	if cost := bc.CalculateBuyPrice(0, bc.MaxSupply); cost != units.MaxLamports {
+		t.Errorf("buying the whole supply costs %d; want MaxLamports", cost)
+	}
+	if tokens := bc.CalculateTokensForSol(0, units.MaxLamports/2); tokens >= bc.MaxSupply {
+		t.Errorf("huge SOL amount bought %d tokens; want less than max supply", tokens)
+	}
+}
+
+func TestNewFromParamsRejectsOverflowingCurves(t *testing.T) {
+	// e^1000 at max supply
+	if _, err := NewFromParams(CurveSpec{K: 0.000001}, 0); err == nil {
+		t.Error("exponential curve overflowing float64 accepted")
+	}
+	// e^30 times base price overflows int64 micro-lamports
+	if _, err := NewFromParams(CurveSpec{K: 0.00000003}, 0); err == nil {
+		t.Error("exponential curve overflowing stored prices accepted")
+	}
+}
+
+func TestNewFromParamsValidatesCurveParams(t *testing.T) {
+	tests := map[string]CurveSpec{
+		"unknown type":        {Type: "quadratic"},
+		"missing params":      {Type: CurveTypeSigmoid},
+		"unknown param field": {Type: CurveTypeLogarithmic, Params: json.RawMessage(`{"scale":1,"offset":"1","foo":1}`)},
+		"sigmoid max below base": {Type: CurveTypeSigmoid, Params: json.RawMessage(
+			`{"maxPrice":"1","midpoint":"0","steepness":1}`)},
+		"piecewise not from zero": {Type: CurveTypePiecewise, Params: json.RawMessage(
+			`{"segments":[{"from":"5","price":"1","slope":0}]}`)},
+		"piecewise step down": {Type: CurveTypePiecewise, Params: json.RawMessage(
+			`{"segments":[{"from":"0","price":"2000","slope":0},{"from":"1000000","price":"1000","slope":0}]}`)},
+	}
+	for name, spec := range tests {
+		if _, err := NewFromParams(spec, 0); err == nil {
+			t.Errorf("%s: accepted", name)
+		}
+	}
+}
+
+func TestRegisteredCurvesListsBuiltins(t *testing.T) {
+	registered := map[CurveType]bool{}
+	for _, ct := range RegisteredCurves() {
+		registered[ct] = true
+	}
+	for _, ct := range []CurveType{CurveTypeExponential, CurveTypeLinear, CurveTypeConstantProduct,
+		CurveTypeSigmoid, CurveTypeLogarithmic, CurveTypePiecewise} {
+		if !registered[ct] {
+			t.Errorf("%s not registered", ct)
+		}
+	}
+}
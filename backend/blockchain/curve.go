@@ -0,0 +1,166 @@
+package blockchain
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"sync"
+
+	"memepump/units"
+)
+
+// CurveType defines the type of bonding curve
+type CurveType string
+
+const (
+	CurveTypeExponential     CurveType = "exponential"
+	CurveTypeLinear          CurveType = "linear"
+	CurveTypeConstantProduct CurveType = "constant_product"
+	CurveTypeSigmoid         CurveType = "sigmoid"
+	CurveTypeLogarithmic     CurveType = "logarithmic"
+	CurveTypePiecewise       CurveType = "piecewise"
+	CurveTypeVirtualAMM      CurveType = "virtual_amm"
+)
+
+// Curve is a price function of circulating supply.
+// Supplies and amounts are whole tokens, prices SOL per token and costs SOL;
+// BondingCurve converts to and from integer base units.
+type Curve interface {
+	// Price returns the spot price at supply
+	Price(supply float64) float64
+	// Integral returns the cost of buying amount tokens starting at supply
+	Integral(supply, amount float64) float64
+	// Inverse returns how many tokens cost buys starting at supply
+	Inverse(supply, cost float64) float64
+	// Spec returns the parameters the curve was built from
+	Spec() CurveSpec
+}
+
+// ReserveCurve is a curve backed by virtual AMM reserves.
+// Coins on these curves graduate when the real token reserve (maxSupply) is sold out.
+type ReserveCurve interface {
+	Curve
+	// Reserves returns the virtual SOL and token reserves after supply tokens were bought
+	Reserves(supply float64) (sol, tokens float64)
+}
+
+// CurveSpec is the stored, user-facing description of a curve
+type CurveSpec struct {
+	Type      CurveType       `json:"type"`
+	BasePrice units.Price     `json:"basePrice"`
+	K         float64         `json:"k,omitempty"`
+	Slope     float64         `json:"slope,omitempty"`
+	Params    json.RawMessage `json:"params,omitempty"` // Type-specific parameters
+	MaxSupply units.Tokens    `json:"maxSupply"`
+}
+
+// CurveFactory validates a spec and builds its curve
+type CurveFactory func(spec CurveSpec) (Curve, error)
+
+// Curve parameter validation errors
+var (
+	ErrUnknownCurveType = errors.New("unknown curve type")
+	ErrInvalidCurve     = errors.New("invalid curve parameters")
+)
+
+var (
+	curveRegistryMu sync.RWMutex
+	curveRegistry   = map[CurveType]CurveFactory{}
+)
+
+// RegisterCurve makes a curve type available to coin creation.
+// Registering the same type twice replaces the earlier factory.
+func RegisterCurve(curveType CurveType, factory CurveFactory) {
+	curveRegistryMu.Lock()
+	defer curveRegistryMu.Unlock()
+	curveRegistry[curveType] = factory
+}
+
+// RegisteredCurves returns the available curve types in name order
+func RegisteredCurves() []CurveType {
+	curveRegistryMu.RLock()
+	defer curveRegistryMu.RUnlock()
+
+	types := make([]CurveType, 0, len(curveRegistry))
+	for t := range curveRegistry {
+		types = append(types, t)
+	}
+	sort.Slice(types, func(i, j int) bool { return types[i] < types[j] })
+	return types
+}
+
+// NewCurve builds a curve from its spec through the registry
+func NewCurve(spec CurveSpec) (Curve, error) {
+	curveRegistryMu.RLock()
+	factory, ok := curveRegistry[spec.Type]
+	curveRegistryMu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("%w: %q", ErrUnknownCurveType, spec.Type)
+	}
+	if spec.MaxSupply <= 0 {
+		return nil, invalidCurve("maxSupply must be positive")
+	}
+	return factory(spec)
+}
+
+func invalidCurve(format string, args ...interface{}) error {
+	return fmt.Errorf("%w: "+format, append([]interface{}{ErrInvalidCurve}, args...)...)
+}
+
+// decodeParams unmarshals type-specific parameters, rejecting unknown fields
+func decodeParams(spec CurveSpec, target interface{}) error {
+	if len(spec.Params) == 0 {
+		return invalidCurve("%s curve requires params", spec.Type)
+	}
+	dec := json.NewDecoder(bytes.NewReader(spec.Params))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(target); err != nil {
+		return invalidCurve("params: %v", err)
+	}
+	return nil
+}
+
+// curveSamples is how many points checkShape inspects across the supply
+const curveSamples = 256
+
+// checkShape verifies a curve is positive, finite, storable and non-decreasing up to maxSupply.
+// Factories validate their own parameters; this catches combinations they cannot reason about.
+func checkShape(c Curve, maxSupply units.Tokens) error {
+	max := maxSupply.Float()
+	prev := 0.0
+	for i := 0; i <= curveSamples; i++ {
+		s := max * float64(i) / curveSamples
+		p := c.Price(s)
+		switch {
+		case math.IsNaN(p) || math.IsInf(p, 0):
+			return invalidCurve("price is not finite at supply %.0f", s)
+		case p <= 0:
+			return invalidCurve("price must be positive at supply %.0f", s)
+		case p*units.LamportsPerSol*units.PriceScale >= math.MaxInt64:
+			// Prices are stored as int64 micro-lamports
+			return invalidCurve("price at supply %.0f is out of range", s)
+		case p < prev:
+			return invalidCurve("price must not decrease with supply")
+		}
+		prev = p
+	}
+	return nil
+}
+
+// invertNumerically solves Integral(supply, x) = cost by bisection for curves
+// without a closed-form inverse. Price is non-decreasing, so x <= cost / Price(supply).
+func invertNumerically(c Curve, supply, cost float64) float64 {
+	lo, hi := 0.0, cost/c.Price(supply)
+	for i := 0; i < 200 && hi-lo > hi*1e-15; i++ {
+		mid := (lo + hi) / 2
+		if c.Integral(supply, mid) <= cost {
+			lo = mid
+		} else {
+			hi = mid
+		}
+	}
+	return lo
+}
@@ -0,0 +1,469 @@
+package blockchain
+
+import (
+	"encoding/json"
+	"math"
+	"sort"
+
+	"memepump/units"
+)
+
+func init() {
+	RegisterCurve(CurveTypeExponential, newExponentialCurve)
+	RegisterCurve(CurveTypeLinear, newLinearCurve)
+	RegisterCurve(CurveTypeConstantProduct, newConstantProductCurve)
+	RegisterCurve(CurveTypeSigmoid, newSigmoidCurve)
+	RegisterCurve(CurveTypeLogarithmic, newLogarithmicCurve)
+	RegisterCurve(CurveTypePiecewise, newPiecewiseCurve)
+	RegisterCurve(CurveTypeVirtualAMM, newVirtualAMMCurve)
+}
+
+// maxExpExponent keeps math.Exp well inside float64 range for exponential curves
+const maxExpExponent = 700.0
+
+// ========================================
+// Exponential: price = basePrice * e^(k * supply)
+// ========================================
+
+type exponentialCurve struct {
+	spec CurveSpec
+	base float64
+}
+
+func newExponentialCurve(spec CurveSpec) (Curve, error) {
+	if spec.BasePrice <= 0 {
+		return nil, invalidCurve("basePrice must be positive")
+	}
+	if spec.K < 0 {
+		return nil, invalidCurve("k must not be negative")
+	}
+	if spec.K*spec.MaxSupply.Float() > maxExpExponent {
+		return nil, invalidCurve("k * maxSupply must not exceed %.0f", maxExpExponent)
+	}
+	c := &exponentialCurve{spec: spec, base: spec.BasePrice.SOL()}
+	return c, checkShape(c, spec.MaxSupply)
+}
+
+func (c *exponentialCurve) Price(supply float64) float64 {
+	// More aggressive growth as supply increases
+	return c.base * math.Exp(c.spec.K*supply)
+}
+
+func (c *exponentialCurve) Integral(supply, amount float64) float64 {
+	// ∫ basePrice * e^(k*s) ds = price(supply)/k * (e^(k*amount) - 1)
+	if c.spec.K == 0 {
+		return c.base * amount
+	}
+	return c.Price(supply) / c.spec.K * math.Expm1(c.spec.K*amount)
+}
+
+func (c *exponentialCurve) Inverse(supply, cost float64) float64 {
+	if c.spec.K == 0 {
+		return cost / c.base
+	}
+	return math.Log1p(cost*c.spec.K/c.Price(supply)) / c.spec.K
+}
+
+func (c *exponentialCurve) Spec() CurveSpec { return c.spec }
+
+// ========================================
+// Linear: price = basePrice + slope * supply
+// ========================================
+
+type linearCurve struct {
+	spec CurveSpec
+	base float64
+}
+
+func newLinearCurve(spec CurveSpec) (Curve, error) {
+	if spec.BasePrice <= 0 {
+		return nil, invalidCurve("basePrice must be positive")
+	}
+	if spec.Slope < 0 {
+		return nil, invalidCurve("slope must not be negative")
+	}
+	c := &linearCurve{spec: spec, base: spec.BasePrice.SOL()}
+	return c, checkShape(c, spec.MaxSupply)
+}
+
+func (c *linearCurve) Price(supply float64) float64 {
+	// Predictable, steady price increase
+	return c.base + c.spec.Slope*supply
+}
+
+func (c *linearCurve) Integral(supply, amount float64) float64 {
+	// Trapezoid is exact for a straight line
+	return amount * (c.Price(supply) + c.spec.Slope*amount/2)
+}
+
+func (c *linearCurve) Inverse(supply, cost float64) float64 {
+	return solveLinear(c.Price(supply), c.spec.Slope, cost)
+}
+
+func (c *linearCurve) Spec() CurveSpec { return c.spec }
+
+// solveLinear returns x with price*x + slope/2*x^2 = cost, in its cancellation-free form
+func solveLinear(price, slope, cost float64) float64 {
+	return 2 * cost / (price + math.Sqrt(price*price+2*slope*cost))
+}
+
+// ========================================
+// Constant product: price = k / (maxSupply - supply)
+// ========================================
+
+type constantProductCurve struct {
+	spec      CurveSpec
+	maxSupply float64
+}
+
+func newConstantProductCurve(spec CurveSpec) (Curve, error) {
+	if spec.K <= 0 {
+		return nil, invalidCurve("k must be positive")
+	}
+	c := &constantProductCurve{spec: spec, maxSupply: spec.MaxSupply.Float()}
+	return c, checkShape(c, spec.MaxSupply)
+}
+
+func (c *constantProductCurve) Price(supply float64) float64 {
+	// Uniswap-style: x * y = k
+	remaining := c.maxSupply - supply
+	if remaining <= 1 {
+		remaining = 1 // Prevent division by zero
+	}
+	return c.spec.K / remaining
+}
+
+func (c *constantProductCurve) Integral(supply, amount float64) float64 {
+	// ∫ k / (maxSupply - s) ds = -k * ln(1 - amount/(maxSupply - supply))
+	remaining := c.maxSupply - supply
+	if amount >= remaining {
+		return math.Inf(1)
+	}
+	return -c.spec.K * math.Log1p(-amount/remaining)
+}
+
+func (c *constantProductCurve) Inverse(supply, cost float64) float64 {
+	// maxSupply - b = (maxSupply - supply) * e^(-cost/k)
+	return -(c.maxSupply - supply) * math.Expm1(-cost/c.spec.K)
+}
+
+func (c *constantProductCurve) Spec() CurveSpec { return c.spec }
+
+// ========================================
+// Sigmoid: slow start, steep middle, flattening towards maxPrice
+// price = basePrice + (maxPrice - basePrice) / (1 + e^(-steepness * (supply - midpoint)))
+// ========================================
+
+// SigmoidParams are the params of a sigmoid curve
+type SigmoidParams struct {
+	MaxPrice  units.Price  `json:"maxPrice"`
+	Midpoint  units.Tokens `json:"midpoint"`
+	Steepness float64      `json:"steepness"` // Per whole token
+}
+
+type sigmoidCurve struct {
+	spec                       CurveSpec
+	base, span, mid, steepness float64
+}
+
+func newSigmoidCurve(spec CurveSpec) (Curve, error) {
+	var p SigmoidParams
+	if err := decodeParams(spec, &p); err != nil {
+		return nil, err
+	}
+	if spec.BasePrice <= 0 {
+		return nil, invalidCurve("basePrice must be positive")
+	}
+	if p.MaxPrice <= spec.BasePrice {
+		return nil, invalidCurve("maxPrice must exceed basePrice")
+	}
+	if p.Midpoint < 0 || p.Midpoint > spec.MaxSupply {
+		return nil, invalidCurve("midpoint must lie within maxSupply")
+	}
+	if p.Steepness <= 0 {
+		return nil, invalidCurve("steepness must be positive")
+	}
+
+	c := &sigmoidCurve{
+		spec:      spec,
+		base:      spec.BasePrice.SOL(),
+		span:      p.MaxPrice.SOL() - spec.BasePrice.SOL(),
+		mid:       p.Midpoint.Float(),
+		steepness: p.Steepness,
+	}
+	return c, checkShape(c, spec.MaxSupply)
+}
+
+func (c *sigmoidCurve) Price(supply float64) float64 {
+	return c.base + c.span/(1+math.Exp(-c.steepness*(supply-c.mid)))
+}
+
+func (c *sigmoidCurve) Integral(supply, amount float64) float64 {
+	// ∫ span / (1 + e^(-t(s-m))) ds = span/t * softplus(t(s-m))
+	lo := softplus(c.steepness * (supply - c.mid))
+	hi := softplus(c.steepness * (supply + amount - c.mid))
+	return c.base*amount + c.span/c.steepness*(hi-lo)
+}
+
+func (c *sigmoidCurve) Inverse(supply, cost float64) float64 {
+	return invertNumerically(c, supply, cost)
+}
+
+func (c *sigmoidCurve) Spec() CurveSpec { return c.spec }
+
+// softplus returns ln(1 + e^x) without overflow
+func softplus(x float64) float64 {
+	return math.Max(x, 0) + math.Log1p(math.Exp(-math.Abs(x)))
+}
+
+// ========================================
+// Logarithmic: fast early growth that keeps slowing
+// price = basePrice + scale * ln(1 + supply/offset)
+// ========================================
+
+// LogarithmicParams are the params of a logarithmic curve
+type LogarithmicParams struct {
+	Scale  float64      `json:"scale"`  // SOL added per e-fold of (1 + supply/offset)
+	Offset units.Tokens `json:"offset"` // Supply at which growth starts to flatten
+}
+
+type logarithmicCurve struct {
+	spec                CurveSpec
+	base, scale, offset float64
+}
+
+func newLogarithmicCurve(spec CurveSpec) (Curve, error) {
+	var p LogarithmicParams
+	if err := decodeParams(spec, &p); err != nil {
+		return nil, err
+	}
+	if spec.BasePrice <= 0 {
+		return nil, invalidCurve("basePrice must be positive")
+	}
+	if p.Scale < 0 {
+		return nil, invalidCurve("scale must not be negative")
+	}
+	if p.Offset <= 0 {
+		return nil, invalidCurve("offset must be positive")
+	}
+
+	c := &logarithmicCurve{
+		spec:   spec,
+		base:   spec.BasePrice.SOL(),
+		scale:  p.Scale,
+		offset: p.Offset.Float(),
+	}
+	return c, checkShape(c, spec.MaxSupply)
+}
+
+func (c *logarithmicCurve) Price(supply float64) float64 {
+	return c.base + c.scale*math.Log1p(supply/c.offset)
+}
+
+func (c *logarithmicCurve) Integral(supply, amount float64) float64 {
+	// ∫ ln(1 + s/c) ds = (s + c) * ln(1 + s/c) - s
+	antiderivative := func(s float64) float64 {
+		return (s+c.offset)*math.Log1p(s/c.offset) - s
+	}
+	return c.base*amount + c.scale*(antiderivative(supply+amount)-antiderivative(supply))
+}
+
+func (c *logarithmicCurve) Inverse(supply, cost float64) float64 {
+	return invertNumerically(c, supply, cost)
+}
+
+func (c *logarithmicCurve) Spec() CurveSpec { return c.spec }
+
+// ========================================
+// Piecewise: linear segments, or flat steps when slope is zero
+// ========================================
+
+// PiecewiseSegment starts at From and prices linearly until the next segment
+type PiecewiseSegment struct {
+	From  units.Tokens `json:"from"`
+	Price units.Price  `json:"price"`
+	Slope float64      `json:"slope"` // SOL per token per token, 0 for a flat step
+}
+
+// PiecewiseParams are the params of a piecewise curve
+type PiecewiseParams struct {
+	Segments []PiecewiseSegment `json:"segments"`
+}
+
+type piecewiseSegment struct {
+	from, price, slope float64
+}
+
+type piecewiseCurve struct {
+	spec     CurveSpec
+	segments []piecewiseSegment
+}
+
+func newPiecewiseCurve(spec CurveSpec) (Curve, error) {
+	var p PiecewiseParams
+	if err := decodeParams(spec, &p); err != nil {
+		return nil, err
+	}
+	if len(p.Segments) == 0 || p.Segments[0].From != 0 {
+		return nil, invalidCurve("first segment must start at supply 0")
+	}
+
+	c := &piecewiseCurve{segments: make([]piecewiseSegment, len(p.Segments))}
+	for i, seg := range p.Segments {
+		if seg.Price <= 0 || seg.Slope < 0 {
+			return nil, invalidCurve("segment %d needs a positive price and non-negative slope", i)
+		}
+		c.segments[i] = piecewiseSegment{from: seg.From.Float(), price: seg.Price.SOL(), slope: seg.Slope}
+		if i == 0 {
+			continue
+		}
+		if seg.From <= p.Segments[i-1].From {
+			return nil, invalidCurve("segments must start at increasing supplies")
+		}
+		// A step down at a boundary would let buyers sell back across it at a profit
+		prev := c.segments[i-1]
+		if seg.Price < units.PriceFromSol(prev.price+prev.slope*(c.segments[i].from-prev.from)) {
+			return nil, invalidCurve("segment %d starts below the previous segment's price", i)
+		}
+	}
+
+	// The first segment sets the launch price
+	spec.BasePrice = p.Segments[0].Price
+	c.spec = spec
+	return c, checkShape(c, spec.MaxSupply)
+}
+
+// segmentAt returns the index of the segment containing supply
+func (c *piecewiseCurve) segmentAt(supply float64) int {
+	return sort.Search(len(c.segments), func(i int) bool { return c.segments[i].from > supply }) - 1
+}
+
+// segmentEnd returns where segment i stops applying
+func (c *piecewiseCurve) segmentEnd(i int) float64 {
+	if i+1 < len(c.segments) {
+		return c.segments[i+1].from
+	}
+	return math.Inf(1)
+}
+
+func (c *piecewiseCurve) Price(supply float64) float64 {
+	seg := c.segments[c.segmentAt(supply)]
+	return seg.price + seg.slope*(supply-seg.from)
+}
+
+func (c *piecewiseCurve) Integral(supply, amount float64) float64 {
+	total := 0.0
+	for i := c.segmentAt(supply); amount > 0; i++ {
+		part := math.Min(amount, c.segmentEnd(i)-supply)
+		seg := c.segments[i]
+		total += part * (seg.price + seg.slope*(supply-seg.from) + seg.slope*part/2)
+		supply += part
+		amount -= part
+	}
+	return total
+}
+
+func (c *piecewiseCurve) Inverse(supply, cost float64) float64 {
+	tokens := 0.0
+	for i := c.segmentAt(supply); cost > 0; i++ {
+		seg := c.segments[i]
+		price := seg.price + seg.slope*(supply-seg.from)
+		room := c.segmentEnd(i) - supply
+		if segCost := room * (price + seg.slope*room/2); segCost < cost {
+			tokens += room
+			cost -= segCost
+			supply += room
+			continue
+		}
+		return tokens + solveLinear(price, seg.slope, cost)
+	}
+	return tokens
+}
+
+func (c *piecewiseCurve) Spec() CurveSpec { return c.spec }
+
+// ========================================
+// Virtual AMM: pump.fun style x * y = k over virtual SOL and token reserves.
+// maxSupply is the real token reserve; the virtual token reserve beyond it
+// keeps the price finite when the real reserve runs out.
+// ========================================
+
+// VirtualAMMParams are the launch reserves of a virtual AMM curve
+type VirtualAMMParams struct {
+	VirtualSolReserves   units.Lamports `json:"virtualSolReserves"`
+	VirtualTokenReserves units.Tokens   `json:"virtualTokenReserves"`
+}
+
+// Launch reserves matching pump.fun
+var (
+	DefaultVirtualSolReserves   = units.Lamports(30 * units.LamportsPerSol)
+	DefaultVirtualTokenReserves = units.Tokens(1073000000 * units.TokenUnit)
+	DefaultRealTokenReserves    = units.Tokens(793100000 * units.TokenUnit)
+)
+
+type virtualAMMCurve struct {
+	spec        CurveSpec
+	sol, tokens float64 // Virtual reserves at launch
+	k           float64
+}
+
+func newVirtualAMMCurve(spec CurveSpec) (Curve, error) {
+	p := VirtualAMMParams{
+		VirtualSolReserves:   DefaultVirtualSolReserves,
+		VirtualTokenReserves: DefaultVirtualTokenReserves,
+	}
+	if len(spec.Params) > 0 {
+		if err := decodeParams(spec, &p); err != nil {
+			return nil, err
+		}
+	}
+	if p.VirtualSolReserves <= 0 {
+		return nil, invalidCurve("virtualSolReserves must be positive")
+	}
+	if p.VirtualTokenReserves <= spec.MaxSupply {
+		return nil, invalidCurve("virtualTokenReserves must exceed maxSupply (the real token reserves)")
+	}
+
+	// Store the effective reserves so the coin keeps them if the defaults change
+	params, err := json.Marshal(p)
+	if err != nil {
+		return nil, err
+	}
+	spec.Params = params
+	spec.K = 0
+	spec.Slope = 0
+	spec.BasePrice = units.PriceOf(p.VirtualSolReserves, p.VirtualTokenReserves)
+
+	c := &virtualAMMCurve{
+		spec:   spec,
+		sol:    p.VirtualSolReserves.SOL(),
+		tokens: p.VirtualTokenReserves.Float(),
+	}
+	c.k = c.sol * c.tokens
+	return c, checkShape(c, spec.MaxSupply)
+}
+
+// Reserves returns the virtual SOL and token reserves after supply tokens were bought
+func (c *virtualAMMCurve) Reserves(supply float64) (sol, tokens float64) {
+	tokens = c.tokens - supply
+	return c.k / tokens, tokens
+}
+
+func (c *virtualAMMCurve) Price(supply float64) float64 {
+	sol, tokens := c.Reserves(supply)
+	return sol / tokens
+}
+
+func (c *virtualAMMCurve) Integral(supply, amount float64) float64 {
+	// k/(y - amount) - k/y, rearranged to avoid cancellation on small trades
+	sol, tokens := c.Reserves(supply)
+	return sol * amount / (tokens - amount)
+}
+
+func (c *virtualAMMCurve) Inverse(supply, cost float64) float64 {
+	// y - k/(x + cost) = y * cost / (x + cost)
+	sol, tokens := c.Reserves(supply)
+	return tokens * cost / (sol + cost)
+}
+
+func (c *virtualAMMCurve) Spec() CurveSpec { return c.spec }
@@ -0,0 +1,110 @@
+package blockchain
+
+import (
+	"encoding/json"
+	"math"
+
+	"memepump/units"
+)
+
+// DesignTarget describes a curve in launch terms: the price it starts at and the
+// market cap it graduates at once GraduationSupply tokens are sold
+type DesignTarget struct {
+	Type             CurveType
+	StartPrice       units.Price
+	TargetMcap       units.Lamports
+	GraduationSupply units.Tokens
+	MaxSupply        units.Tokens // Optional for exponential and linear curves, solved for the others
+}
+
+// DesignableCurves are the curve types DesignCurve can solve for
+var DesignableCurves = []CurveType{CurveTypeExponential, CurveTypeLinear, CurveTypeConstantProduct, CurveTypeVirtualAMM}
+
+// DesignCurve solves the parameters of a curve of the target's type that starts at
+// StartPrice and reaches TargetMcap at GraduationSupply. The result is built and
+// validated like any stored curve, so an unreachable target fails with ErrInvalidCurve.
+func DesignCurve(target DesignTarget) (*BondingCurve, error) {
+	if target.Type == "" {
+		target.Type = CurveTypeExponential
+	}
+	if target.StartPrice <= 0 || target.TargetMcap <= 0 || target.GraduationSupply <= 0 {
+		return nil, invalidCurve("startPrice, targetMcap and graduationSupply must be positive")
+	}
+
+	supply := target.GraduationSupply.Float()
+	start := target.StartPrice.SOL()
+	end := target.TargetMcap.SOL() / supply // Price at which supply is worth the target
+	if end < start {
+		return nil, invalidCurve("targetMcap must be at least startPrice * graduationSupply (%.4f SOL)", start*supply)
+	}
+
+	spec := CurveSpec{Type: target.Type, BasePrice: target.StartPrice, MaxSupply: target.MaxSupply}
+	switch target.Type {
+	case CurveTypeExponential:
+		// base * e^(k * supply) = end
+		if end == start {
+			return nil, invalidCurve("use a linear curve with no slope for a flat price")
+		}
+		spec.K = math.Log(end/start) / supply
+		if spec.K*supply > maxExpExponent {
+			return nil, invalidCurve("price would grow by more than e^%.0f before graduation", maxExpExponent)
+		}
+		if spec.MaxSupply == 0 {
+			spec.MaxSupply = defaultDesignSupply(target.GraduationSupply)
+			if spec.K*spec.MaxSupply.Float() > maxExpExponent {
+				spec.MaxSupply = target.GraduationSupply
+			}
+		}
+	case CurveTypeLinear:
+		// base + slope * supply = end
+		spec.Slope = (end - start) / supply
+		if spec.MaxSupply == 0 {
+			spec.MaxSupply = defaultDesignSupply(target.GraduationSupply)
+		}
+	case CurveTypeConstantProduct:
+		// k / max = start and k / (max - supply) = end
+		if target.MaxSupply != 0 {
+			return nil, invalidCurve("maxSupply is solved for constant_product curves")
+		}
+		if end == start {
+			return nil, invalidCurve("constant_product curves cannot stay flat")
+		}
+		max := supply * end / (end - start)
+		spec.MaxSupply = units.TokensFromFloat(max)
+		spec.K = start * max
+	case CurveTypeVirtualAMM:
+		// x / y = start and x * y / (y - supply)^2 = end; the real reserve sells out at graduation
+		if target.MaxSupply != 0 {
+			return nil, invalidCurve("maxSupply is the graduation supply for virtual_amm curves")
+		}
+		if end == start {
+			return nil, invalidCurve("virtual_amm curves cannot stay flat")
+		}
+		ratio := math.Sqrt(end / start)
+		tokens := supply * ratio / (ratio - 1)
+		params, err := json.Marshal(VirtualAMMParams{
+			VirtualSolReserves:   units.LamportsFromSol(start * tokens),
+			VirtualTokenReserves: units.TokensFromFloat(tokens),
+		})
+		if err != nil {
+			return nil, err
+		}
+		spec.Params = params
+		spec.MaxSupply = target.GraduationSupply
+	default:
+		return nil, invalidCurve("cannot design %q curves", target.Type)
+	}
+
+	if spec.MaxSupply < target.GraduationSupply {
+		return nil, invalidCurve("maxSupply must be at least graduationSupply")
+	}
+	return NewFromParams(spec, target.TargetMcap)
+}
+
+// defaultDesignSupply leaves room past graduation like the default curve does
+func defaultDesignSupply(graduation units.Tokens) units.Tokens {
+	if graduation > DefaultMaxSupply {
+		return graduation
+	}
+	return DefaultMaxSupply
+}
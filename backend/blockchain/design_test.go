@@ -0,0 +1,53 @@
+package blockchain
+
+import (
+	"errors"
+	"math"
+	"testing"
+
+	"memepump/units"
+)
+
+func TestDesignCurveHitsTargets(t *testing.T) {
+	target := DesignTarget{
+		StartPrice:       units.PriceFromSol(0.000001),
+		TargetMcap:       units.LamportsFromSol(4000),
+		GraduationSupply: units.Tokens(800000000 * units.TokenUnit),
+	}
+	for _, curveType := range DesignableCurves {
+		target.Type = curveType
+		bc, err := DesignCurve(target)
+		if err != nil {
+			t.Errorf("%s: %v", curveType, err)
+			continue
+		}
+		if start := bc.CalculatePrice(0).SOL(); math.Abs(start/target.StartPrice.SOL()-1) > 1e-6 {
+			t.Errorf("%s starts at %g SOL; want %g", curveType, start, target.StartPrice.SOL())
+		}
+		mcap := bc.CalculateMarketCap(target.GraduationSupply)
+		if math.Abs(mcap.SOL()/target.TargetMcap.SOL()-1) > 1e-6 {
+			t.Errorf("%s is worth %f SOL at graduation supply; want %f", curveType, mcap.SOL(), target.TargetMcap.SOL())
+		}
+		if bc.MaxSupply < target.GraduationSupply {
+			t.Errorf("%s max supply %d is below the graduation supply", curveType, bc.MaxSupply)
+		}
+	}
+}
+
+func TestDesignCurveRejectsInfeasibleTargets(t *testing.T) {
+	supply := units.Tokens(1000000 * units.TokenUnit)
+	tests := []struct {
+		name   string
+		target DesignTarget
+	}{
+		{"below start", DesignTarget{StartPrice: units.PriceFromSol(0.001), TargetMcap: units.LamportsFromSol(1), GraduationSupply: supply}},
+		{"price out of range", DesignTarget{StartPrice: 1, TargetMcap: units.MaxLamports / 2, GraduationSupply: 1}},
+		{"unknown type", DesignTarget{Type: CurveTypeSigmoid, StartPrice: 1, TargetMcap: 1000, GraduationSupply: supply}},
+		{"small max supply", DesignTarget{Type: CurveTypeLinear, StartPrice: 1, TargetMcap: 1000, GraduationSupply: supply, MaxSupply: 1}},
+	}
+	for _, test := range tests {
+		if _, err := DesignCurve(test.target); !errors.Is(err, ErrInvalidCurve) {
+			t.Errorf("%s: error = %v; want ErrInvalidCurve", test.name, err)
+		}
+	}
+}
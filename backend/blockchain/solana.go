@@ -7,7 +7,10 @@ import (
 	"io"
 	"net/http"
 	"os"
+	"strconv"
 	"time"
+
+	"memepump/units"
 )
 
 // SolanaClient handles Solana RPC interactions
@@ -156,8 +159,8 @@ func (b *bodyReader) Read(p []byte) (n int, err error) {
 	return n, nil
 }
 
-// GetTokenSupply returns the current supply of a token
-func (s *SolanaClient) GetTokenSupply(ctx context.Context, mintAddress string) (uint64, error) {
+// GetTokenSupply returns the current supply of a token, rescaled to units.TokenDecimals
+func (s *SolanaClient) GetTokenSupply(ctx context.Context, mintAddress string) (units.Tokens, error) {
 	result, err := s.call(ctx, "getTokenSupply", []interface{}{mintAddress})
 	if err != nil {
 		return 0, err
@@ -177,13 +180,15 @@ func (s *SolanaClient) GetTokenSupply(ctx context.Context, mintAddress string) (
 		return 0, fmt.Errorf("failed to parse supply response: %w", err)
 	}
 
-	var supply uint64
-	fmt.Sscanf(supplyResp.Value.Amount, "%d", &supply)
-	return supply, nil
+	supply, err := strconv.ParseUint(supplyResp.Value.Amount, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("failed to parse supply amount: %w", err)
+	}
+	return units.Rescale(supply, supplyResp.Value.Decimals), nil
 }
 
 // GetBalance returns the SOL balance of an account
-func (s *SolanaClient) GetBalance(ctx context.Context, address string) (uint64, error) {
+func (s *SolanaClient) GetBalance(ctx context.Context, address string) (units.Lamports, error) {
 	result, err := s.call(ctx, "getBalance", []interface{}{address})
 	if err != nil {
 		return 0, err
@@ -200,7 +205,7 @@ func (s *SolanaClient) GetBalance(ctx context.Context, address string) (uint64,
 		return 0, fmt.Errorf("failed to parse balance response: %w", err)
 	}
 
-	return balanceResp.Value, nil
+	return units.Lamports(balanceResp.Value), nil
 }
 
 // GetRecentBlockhash returns a recent blockhash for transactions
@@ -265,6 +270,63 @@ func (s *SolanaClient) ConfirmTransaction(ctx context.Context, signature string)
 	return status.ConfirmationStatus == "finalized" || status.ConfirmationStatus == "confirmed", nil
 }
 
+// GetReceivedLamports returns how many lamports address gained in a confirmed transaction,
+// and the fee payer that sent them
+func (s *SolanaClient) GetReceivedLamports(ctx context.Context, signature, address string) (units.Lamports, string, error) {
+	result, err := s.call(ctx, "getTransaction", []interface{}{
+		signature,
+		map[string]interface{}{
+			"encoding":                       "jsonParsed",
+			"commitment":                     "confirmed",
+			"maxSupportedTransactionVersion": 0,
+		},
+	})
+	if err != nil {
+		return 0, "", err
+	}
+
+	var txResp *struct {
+		Meta struct {
+			Err          interface{} `json:"err"`
+			PreBalances  []int64     `json:"preBalances"`
+			PostBalances []int64     `json:"postBalances"`
+		} `json:"meta"`
+		Transaction struct {
+			Message struct {
+				AccountKeys []struct {
+					Pubkey string `json:"pubkey"`
+				} `json:"accountKeys"`
+			} `json:"message"`
+		} `json:"transaction"`
+	}
+
+	if err := json.Unmarshal(result, &txResp); err != nil {
+		return 0, "", fmt.Errorf("failed to parse transaction response: %w", err)
+	}
+	if txResp == nil {
+		return 0, "", fmt.Errorf("transaction %s not found or not confirmed", signature)
+	}
+	if txResp.Meta.Err != nil {
+		return 0, "", fmt.Errorf("transaction failed: %v", txResp.Meta.Err)
+	}
+
+	keys := txResp.Transaction.Message.AccountKeys
+	if len(keys) == 0 {
+		return 0, "", fmt.Errorf("transaction %s has no accounts", signature)
+	}
+	for i, key := range keys {
+		if key.Pubkey != address || i >= len(txResp.Meta.PreBalances) || i >= len(txResp.Meta.PostBalances) {
+			continue
+		}
+		received := txResp.Meta.PostBalances[i] - txResp.Meta.PreBalances[i]
+		if received < 0 {
+			received = 0
+		}
+		return units.Lamports(received), keys[0].Pubkey, nil
+	}
+	return 0, keys[0].Pubkey, nil
+}
+
 // GetAccountInfo returns account information
 func (s *SolanaClient) GetAccountInfo(ctx context.Context, address string) (json.RawMessage, error) {
 	return s.call(ctx, "getAccountInfo", []interface{}{
@@ -0,0 +1,205 @@
+// Command curvesim backtests bonding curve parameters. It replays a coin's
+// recorded trades, or synthetic order flow, on the coin's own curve and on a
+// candidate curve, and reports the price path, final market cap, time to
+// graduation, fees and per-trader PnL of both.
+//
+//	curvesim -coin <id> -dsn <postgres dsn> -k 0.00000001 -target-mcap 50000
+//	curvesim -trades trades.json -base-price 0.00002
+//	curvesim -traders 500 -trades-count 5000 -duration 2h -type linear -slope 0.0000001
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"time"
+
+	"memepump/blockchain"
+	"memepump/models"
+	"memepump/trading"
+	"memepump/units"
+
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+func main() {
+	// Order flow
+	coinID := flag.String("coin", "", "replay the recorded trades of this coin")
+	dsn := flag.String("dsn", os.Getenv("DATABASE_URL"), "Postgres DSN for -coin")
+	tradesFile := flag.String("trades", "", "replay trades from a JSON file, as served by GET /api/v1/trades")
+	model := TraderModel{}
+	flag.IntVar(&model.Traders, "traders", 200, "synthetic: number of trading wallets")
+	flag.IntVar(&model.Trades, "trades-count", 2000, "synthetic: number of trades")
+	flag.DurationVar(&model.Duration, "duration", time.Hour, "synthetic: time the trades are spread over")
+	flag.Float64Var(&model.MeanBuy, "mean-buy", 0.5, "synthetic: mean buy in SOL")
+	flag.Float64Var(&model.SellChance, "sell-chance", 0.3, "synthetic: chance a holder's trade is a sell")
+	flag.Int64Var(&model.Seed, "seed", 1, "synthetic: random seed")
+
+	// Candidate curve
+	params := models.CurveParams{}
+	var basePrice, targetMcap, maxSupply float64
+	var curveParams string
+	flag.StringVar(&params.Type, "type", "", "curve type, default exponential")
+	flag.Float64Var(&params.K, "k", 0, "exponential steepness")
+	flag.Float64Var(&params.Slope, "slope", 0, "linear slope")
+	flag.StringVar(&curveParams, "params", "", "JSON params for curve types that need them")
+	flag.Float64Var(&basePrice, "base-price", 0, "starting price in SOL per token")
+	flag.Float64Var(&maxSupply, "max-supply", 0, "curve supply in whole tokens")
+	flag.Float64Var(&targetMcap, "target-mcap", 0, "graduation market cap in SOL")
+
+	fees := trading.Fees
+	flag.Int64Var(&fees.PlatformBps, "platform-bps", fees.PlatformBps, "platform fee in basis points")
+	flag.Int64Var(&fees.CreatorBps, "creator-bps", fees.CreatorBps, "creator fee in basis points")
+
+	asJSON := flag.Bool("json", false, "print full reports as JSON")
+	pathPoints := flag.Int("path", 12, "price path points to print")
+	flag.Parse()
+
+	if err := fees.Validate(); err != nil {
+		log.Fatal(err)
+	}
+	params.BasePrice = units.PriceFromSol(basePrice)
+	params.MaxSupply = units.TokensFromFloat(maxSupply)
+	params.TargetMcap = units.LamportsFromSol(targetMcap)
+	if curveParams != "" {
+		params.Params = json.RawMessage(curveParams)
+	}
+	candidate, err := trading.NewCurve(&params)
+	if err != nil {
+		log.Fatal("Invalid candidate curve: ", err)
+	}
+
+	// The baseline is the coin's own curve, or the default one
+	baseline := blockchain.DefaultCurve()
+	var intents []Intent
+	launch := time.Now()
+	switch {
+	case *coinID != "":
+		coin, trades, err := loadCoin(*dsn, *coinID)
+		if err != nil {
+			log.Fatal(err)
+		}
+		if baseline, err = trading.CurveForCoin(coin); err != nil {
+			log.Fatal("Invalid coin curve: ", err)
+		}
+		launch = coin.CreatedAt
+		intents = IntentsFromTrades(trades)
+	case *tradesFile != "":
+		trades, err := loadTradesFile(*tradesFile)
+		if err != nil {
+			log.Fatal(err)
+		}
+		if len(trades) > 0 {
+			launch = trades[0].Timestamp
+		}
+		intents = IntentsFromTrades(trades)
+	default:
+		if model.Traders <= 0 || model.Trades <= 0 || model.Duration <= 0 || model.MeanBuy <= 0 {
+			log.Fatal("Synthetic order flow needs positive -traders, -trades-count, -duration and -mean-buy")
+		}
+		intents = model.Intents(launch)
+	}
+
+	reports := []*Report{
+		Simulate("baseline", baseline, fees, launch, intents),
+		Simulate("candidate", candidate, fees, launch, intents),
+	}
+
+	if *asJSON {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(reports); err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+	fmt.Printf("%d intents replayed\n", len(intents))
+	for _, report := range reports {
+		printReport(report, *pathPoints)
+	}
+}
+
+// loadCoin reads a coin and its trades in seq order
+func loadCoin(dsn, coinID string) (*models.Coin, []models.Trade, error) {
+	if dsn == "" {
+		return nil, nil, fmt.Errorf("-coin needs -dsn or DATABASE_URL")
+	}
+	db, err := gorm.Open(postgres.Open(dsn), &gorm.Config{Logger: logger.Default.LogMode(logger.Silent)})
+	if err != nil {
+		return nil, nil, fmt.Errorf("connect: %w", err)
+	}
+
+	var coin models.Coin
+	if err := db.First(&coin, "id = ?", coinID).Error; err != nil {
+		return nil, nil, fmt.Errorf("load coin: %w", err)
+	}
+	var trades []models.Trade
+	if err := db.Where("coin_id = ?", coinID).Order("seq, timestamp").Find(&trades).Error; err != nil {
+		return nil, nil, fmt.Errorf("load trades: %w", err)
+	}
+	return &coin, trades, nil
+}
+
+// loadTradesFile reads trades exported from the API, which lists the newest first
+func loadTradesFile(path string) ([]models.Trade, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var trades []models.Trade
+	if err := json.Unmarshal(data, &trades); err != nil {
+		return nil, fmt.Errorf("parse %s: %w", path, err)
+	}
+	for i, j := 0, len(trades)-1; i < j; i, j = i+1, j-1 {
+		trades[i], trades[j] = trades[j], trades[i]
+	}
+	return trades, nil
+}
+
+func printReport(r *Report, pathPoints int) {
+	fmt.Printf("\n== %s: %s curve, base price %.10f SOL, target %.2f SOL\n",
+		r.Name, r.Curve.Type, r.Curve.BasePrice.SOL(), r.TargetMcap.SOL())
+	fmt.Printf("trades %d, skipped %d\n", r.Trades, r.Skipped)
+	if r.Graduated {
+		fmt.Printf("graduated after %s, %d intents left\n", r.TimeToGraduation.Round(time.Second), r.AfterGraduation)
+	} else {
+		fmt.Println("did not graduate")
+	}
+	fmt.Printf("final price %.10f SOL, market cap %.2f SOL, raised %.2f SOL\n",
+		r.FinalPrice.SOL(), r.FinalMarketCap.SOL(), r.Raised.SOL())
+	fmt.Printf("fees: platform %.4f SOL, creator %.4f SOL\n", r.PlatformFees.SOL(), r.CreatorFees.SOL())
+
+	if n := len(r.PricePath); n > 0 && pathPoints > 0 {
+		fmt.Println("price path:")
+		step := (n + pathPoints - 1) / pathPoints
+		for i := 0; i < n; i += step {
+			point := r.PricePath[i]
+			fmt.Printf("  %s  %.10f SOL  mcap %.2f SOL\n", point.Time.Format(time.RFC3339), point.Price.SOL(), point.MarketCap.SOL())
+		}
+		if last := r.PricePath[n-1]; (n-1)%step != 0 {
+			fmt.Printf("  %s  %.10f SOL  mcap %.2f SOL\n", last.Time.Format(time.RFC3339), last.Price.SOL(), last.MarketCap.SOL())
+		}
+	}
+
+	var winners, losers int
+	var pnl units.Lamports
+	for _, trader := range r.Traders {
+		pnl += trader.PnL
+		if trader.PnL > 0 {
+			winners++
+		} else if trader.PnL < 0 {
+			losers++
+		}
+	}
+	fmt.Printf("traders %d: %d up, %d down, net %.4f SOL\n", len(r.Traders), winners, losers, pnl.SOL())
+	for i, trader := range r.Traders {
+		if i == 5 {
+			break
+		}
+		fmt.Printf("  %-44s %+.4f SOL\n", trader.Wallet, trader.PnL.SOL())
+	}
+}
@@ -0,0 +1,150 @@
+package main
+
+import (
+	"sort"
+	"time"
+
+	"memepump/blockchain"
+	"memepump/models"
+	"memepump/trading"
+	"memepump/units"
+)
+
+// Intent is what a trader meant to do, independent of the curve it traded on.
+// Buys keep the SOL spent, sells the share of the position sold, so the same
+// order flow can be replayed against any curve.
+type Intent struct {
+	Time     time.Time      `json:"time"`
+	Wallet   string         `json:"wallet"`
+	Side     string         `json:"side"`
+	Budget   units.Lamports `json:"budget,omitempty"`   // Buys: SOL spent, fees included
+	Fraction float64        `json:"fraction,omitempty"` // Sells: share of the wallet's position sold
+}
+
+// PricePoint is the coin's price after one simulated trade
+type PricePoint struct {
+	Time      time.Time      `json:"time"`
+	Price     units.Price    `json:"price"`
+	MarketCap units.Lamports `json:"marketCap"`
+}
+
+// TraderPnL is one wallet's result, its remaining tokens marked at the final price
+type TraderPnL struct {
+	Wallet   string         `json:"wallet"`
+	Spent    units.Lamports `json:"spent"`
+	Received units.Lamports `json:"received"`
+	Tokens   units.Tokens   `json:"tokens"`
+	Value    units.Lamports `json:"value"`
+	PnL      units.Lamports `json:"pnl"`
+}
+
+// Report summarises a simulation
+type Report struct {
+	Name             string               `json:"name"`
+	Curve            blockchain.CurveSpec `json:"curve"`
+	TargetMcap       units.Lamports       `json:"targetMcap"`
+	Trades           int                  `json:"trades"`
+	Skipped          int                  `json:"skipped"`          // Sells of empty positions and buys too small to fill
+	AfterGraduation  int                  `json:"afterGraduation"`  // Intents left once the curve closed
+	Graduated        bool                 `json:"graduated"`        // Whether the curve hit its target
+	TimeToGraduation time.Duration        `json:"timeToGraduation"` // From launch
+	FinalPrice       units.Price          `json:"finalPrice"`
+	FinalMarketCap   units.Lamports       `json:"finalMarketCap"`
+	Raised           units.Lamports       `json:"raised"` // SOL left in the curve
+	PlatformFees     units.Lamports       `json:"platformFees"`
+	CreatorFees      units.Lamports       `json:"creatorFees"`
+	PricePath        []PricePoint         `json:"pricePath"`
+	Traders          []TraderPnL          `json:"traders"`
+}
+
+// IntentsFromTrades turns a recorded trade history, in seq order, into intents
+func IntentsFromTrades(trades []models.Trade) []Intent {
+	positions := make(map[string]units.Tokens)
+	intents := make([]Intent, 0, len(trades))
+	for _, trade := range trades {
+		intent := Intent{Time: trade.Timestamp, Wallet: trade.Wallet, Side: trade.Type}
+		position := positions[trade.Wallet]
+		switch trade.Type {
+		case trading.SideBuy:
+			intent.Budget = trade.SolAmount + trade.PlatformFee + trade.CreatorFee
+			positions[trade.Wallet] = position + trade.Amount
+		case trading.SideSell:
+			intent.Fraction = 1
+			if trade.Amount < position {
+				intent.Fraction = float64(trade.Amount) / float64(position)
+			}
+			positions[trade.Wallet] = position - trade.Amount
+		}
+		intents = append(intents, intent)
+	}
+	return intents
+}
+
+// Simulate replays intents on a fresh coin with curve, charging fees, until the
+// curve graduates or the intents run out
+func Simulate(name string, curve *blockchain.BondingCurve, fees trading.FeeSchedule, launch time.Time, intents []Intent) *Report {
+	previous := trading.Fees
+	trading.Fees = fees // Settle charges the global schedule
+	defer func() { trading.Fees = previous }()
+
+	var coin models.Coin
+	trading.InitCoin(&coin, curve)
+
+	report := &Report{Name: name, Curve: curve.Curve.Spec(), TargetMcap: curve.TargetMcap}
+	traders := make(map[string]*TraderPnL)
+
+	for i, intent := range intents {
+		trader := traders[intent.Wallet]
+		if trader == nil {
+			trader = &TraderPnL{Wallet: intent.Wallet}
+			traders[intent.Wallet] = trader
+		}
+
+		var amount units.Tokens
+		if intent.Side == trading.SideBuy {
+			amount = trading.TokensForBudget(&coin, curve, intent.Budget, fees)
+		} else {
+			amount = units.Tokens(float64(trader.Tokens) * intent.Fraction)
+		}
+		fill, err := trading.Settle(&coin, curve, intent.Side, amount)
+		if err != nil {
+			report.Skipped++
+			continue
+		}
+
+		report.Trades++
+		report.PlatformFees += fill.PlatformFee
+		report.CreatorFees += fill.CreatorFee
+		if fill.Side == trading.SideBuy {
+			trader.Spent += fill.Total
+			trader.Tokens += fill.Amount
+		} else {
+			trader.Received += fill.Total
+			trader.Tokens -= fill.Amount
+		}
+		report.PricePath = append(report.PricePath, PricePoint{Time: intent.Time, Price: coin.Price, MarketCap: coin.MarketCap})
+
+		if trading.ShouldGraduate(&coin, curve) {
+			report.Graduated = true
+			report.TimeToGraduation = intent.Time.Sub(launch)
+			report.AfterGraduation = len(intents) - i - 1
+			break
+		}
+	}
+
+	report.FinalPrice = coin.Price
+	report.FinalMarketCap = coin.MarketCap
+	report.Raised = coin.RealSolReserves
+	for _, trader := range traders {
+		trader.Value = units.Value(trader.Tokens, coin.Price)
+		trader.PnL = trader.Received + trader.Value - trader.Spent
+		report.Traders = append(report.Traders, *trader)
+	}
+	sort.Slice(report.Traders, func(i, j int) bool {
+		if report.Traders[i].PnL != report.Traders[j].PnL {
+			return report.Traders[i].PnL > report.Traders[j].PnL
+		}
+		return report.Traders[i].Wallet < report.Traders[j].Wallet
+	})
+	return report
+}
@@ -0,0 +1,66 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"memepump/blockchain"
+	"memepump/models"
+	"memepump/trading"
+	"memepump/units"
+)
+
+func TestReplayOnSameCurveReproducesTrades(t *testing.T) {
+	curve := blockchain.DefaultCurve()
+	fees := trading.FeeSchedule{PlatformBps: 100, CreatorBps: 50}
+	previous := trading.Fees
+	trading.Fees = fees
+	t.Cleanup(func() { trading.Fees = previous })
+
+	// Record a history on the curve
+	var coin models.Coin
+	trading.InitCoin(&coin, curve)
+	launch := time.Now()
+	var trades []models.Trade
+	record := func(wallet, side string, amount units.Tokens) {
+		fill, err := trading.Settle(&coin, curve, side, amount)
+		if err != nil {
+			t.Fatalf("%s: %v", side, err)
+		}
+		trades = append(trades, models.Trade{
+			Type: side, Wallet: wallet, Amount: fill.Amount, SolAmount: fill.SolAmount,
+			PlatformFee: fill.PlatformFee, CreatorFee: fill.CreatorFee,
+			Timestamp: launch.Add(time.Duration(len(trades)) * time.Minute),
+		})
+	}
+	record("alice", trading.SideBuy, 8000000*units.TokenUnit)
+	record("bob", trading.SideBuy, 3000000*units.TokenUnit)
+	record("alice", trading.SideSell, 2000000*units.TokenUnit)
+
+	report := Simulate("same", curve, fees, launch, IntentsFromTrades(trades))
+	if report.Trades != len(trades) || report.Skipped != 0 {
+		t.Fatalf("replayed %d trades, skipped %d; want %d, 0", report.Trades, report.Skipped, len(trades))
+	}
+	// SOL budgets buy back the recorded amounts to within rounding
+	if diff := report.FinalMarketCap - coin.MarketCap; diff > units.LamportsPerSol/1000 || diff < -units.LamportsPerSol/1000 {
+		t.Errorf("replayed market cap %d; recorded %d", report.FinalMarketCap, coin.MarketCap)
+	}
+	var platform units.Lamports
+	for _, trade := range trades {
+		platform += trade.PlatformFee
+	}
+	if diff := report.PlatformFees - platform; diff > 3 || diff < -3 {
+		t.Errorf("replayed platform fees %d; recorded %d", report.PlatformFees, platform)
+	}
+
+	// A low target graduates the same flow early
+	low, err := trading.NewCurve(&models.CurveParams{TargetMcap: units.LamportsFromSol(50)})
+	if err != nil {
+		t.Fatalf("NewCurve: %v", err)
+	}
+	report = Simulate("low", low, fees, launch, IntentsFromTrades(trades))
+	if !report.Graduated || report.TimeToGraduation != 0 || report.AfterGraduation != 2 {
+		t.Errorf("low target report = graduated %v after %s, %d left; want the first buy to graduate",
+			report.Graduated, report.TimeToGraduation, report.AfterGraduation)
+	}
+}
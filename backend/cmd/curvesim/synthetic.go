@@ -0,0 +1,51 @@
+package main
+
+import (
+	"fmt"
+	"math/rand"
+	"sort"
+	"time"
+
+	"memepump/trading"
+	"memepump/units"
+)
+
+// TraderModel generates synthetic order flow: trades spread uniformly over
+// Duration by a pool of Traders, buys sized exponentially around MeanBuy, and
+// holders selling part or all of their position with probability SellChance
+type TraderModel struct {
+	Traders    int
+	Trades     int
+	Duration   time.Duration
+	MeanBuy    float64 // SOL
+	SellChance float64
+	Seed       int64
+}
+
+// Intents returns the model's order flow after launch, the same for the same seed
+func (m TraderModel) Intents(launch time.Time) []Intent {
+	rng := rand.New(rand.NewSource(m.Seed))
+
+	offsets := make([]time.Duration, m.Trades)
+	for i := range offsets {
+		offsets[i] = time.Duration(rng.Int63n(int64(m.Duration) + 1))
+	}
+	sort.Slice(offsets, func(i, j int) bool { return offsets[i] < offsets[j] })
+
+	holding := make(map[string]bool)
+	intents := make([]Intent, 0, m.Trades)
+	for _, offset := range offsets {
+		wallet := fmt.Sprintf("trader-%d", rng.Intn(m.Traders))
+		intent := Intent{Time: launch.Add(offset), Wallet: wallet}
+		if holding[wallet] && rng.Float64() < m.SellChance {
+			intent.Side = trading.SideSell
+			intent.Fraction = 0.25 + 0.75*rng.Float64()
+		} else {
+			intent.Side = trading.SideBuy
+			intent.Budget = units.LamportsFromSol(rng.ExpFloat64() * m.MeanBuy)
+			holding[wallet] = true
+		}
+		intents = append(intents, intent)
+	}
+	return intents
+}
@@ -0,0 +1,86 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+
+	"memepump/database"
+	"memepump/trading"
+
+	"gorm.io/gorm"
+)
+
+// runVerify replays every coin's event log through its curve and pool and reports
+// coins whose stored state diverges, repairing them with -repair. It returns the
+// process exit code: 1 if any coin is left diverged.
+func runVerify(args []string) int {
+	flags := flag.NewFlagSet("verify", flag.ExitOnError)
+	repair := flags.Bool("repair", false, "overwrite diverged coin state with the replayed state")
+	coinID := flags.String("coin", "", "verify a single coin")
+	flags.Parse(args)
+
+	coinIDs := []string{*coinID}
+	if *coinID == "" {
+		coinIDs = nil
+		if err := database.DB.Table("coins").Order("created_at").Pluck("id", &coinIDs).Error; err != nil {
+			log.Println("Failed to list coins:", err)
+			return 1
+		}
+	}
+
+	var diverged, repaired int
+	for _, id := range coinIDs {
+		var v *trading.Verification
+		err := database.DB.Transaction(func(tx *gorm.DB) error {
+			var err error
+			v, err = trading.VerifyCoin(tx, id, *repair)
+			return err
+		})
+		if err != nil {
+			log.Printf("Coin %s: %v", id, err)
+			diverged++
+			continue
+		}
+		if len(v.Divergences) == 0 && len(v.Problems) == 0 {
+			continue
+		}
+
+		fmt.Printf("coin %s (%d trades)\n", v.CoinID, v.Trades)
+		for _, d := range v.Divergences {
+			fmt.Printf("  %s: stored %s, log %s\n", d.Field, d.Stored, d.Derived)
+		}
+		for _, problem := range v.Problems {
+			fmt.Printf("  problem: %s\n", problem)
+		}
+		if v.Repaired {
+			fmt.Println("  repaired")
+			repaired++
+		} else {
+			diverged++
+		}
+	}
+
+	fmt.Printf("%d coins verified, %d repaired, %d diverged\n", len(coinIDs), repaired, diverged)
+	if diverged > 0 {
+		return 1
+	}
+	return 0
+}
+
+// runRebuildCandles recomputes candles from the trade history, for one coin or all
+func runRebuildCandles(args []string) int {
+	flags := flag.NewFlagSet("candles", flag.ExitOnError)
+	coinID := flags.String("coin", "", "rebuild a single coin")
+	flags.Parse(args)
+
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		return trading.RebuildCandles(tx, *coinID)
+	})
+	if err != nil {
+		log.Println("Failed to rebuild candles:", err)
+		return 1
+	}
+	fmt.Println("candles rebuilt")
+	return 0
+}
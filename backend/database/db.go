@@ -1,8 +1,10 @@
 package database
 
 import (
+	"fmt"
 	"log"
 	"memepump/models"
+	"memepump/units"
 
 	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
@@ -19,6 +21,13 @@ func Connect(dsn string) {
 
 	log.Println("Connected to Database")
 
+	if err := migrateLegacyUnits(); err != nil {
+		log.Fatal("Failed to migrate amounts to base units:", err)
+	}
+
+	// Creators were free-text usernames before coins recorded the creating user
+	legacyCreators := DB.Migrator().HasTable(&models.Coin{}) && !DB.Migrator().HasColumn(&models.Coin{}, "CreatorUserID")
+
 	// Auto Migrate - including new WalletLink model
 	err = DB.AutoMigrate(
 		&models.Coin{},
@@ -26,9 +35,239 @@ func Connect(dsn string) {
 		&models.Comment{},
 		&models.User{},
 		&models.WalletLink{},
+		&models.FeeEntry{},
+		&models.Balance{},
+		&models.SolAccount{},
+		&models.SolTransaction{},
+		&models.MigrationJob{},
+		&models.Pool{},
+		&models.LPPosition{},
+		&models.LockEvent{},
+		&models.Order{},
+		&models.DCAPlan{},
+		&models.DCARun{},
+		&models.IdempotencyKey{},
+		&models.LaunchAllowlistEntry{},
+		&models.LiquidityEvent{},
+		&models.Candle{},
 	)
 	if err != nil {
 		log.Fatal("Failed to auto migrate:", err)
 	}
 	log.Println("Database Migration Completed")
+
+	// Superseded by the unique sequence indexes
+	for _, index := range []string{"idx_trades_coin_seq", "idx_liquidity_events_coin_seq"} {
+		if err := DB.Exec("DROP INDEX IF EXISTS " + index).Error; err != nil {
+			log.Fatal("Failed to drop index "+index+":", err)
+		}
+	}
+
+	if legacyCreators {
+		if err := backfillCreatorUserIDs(); err != nil {
+			log.Fatal("Failed to backfill creator user IDs:", err)
+		}
+	}
+	if err := backfillBalances(); err != nil {
+		log.Fatal("Failed to backfill balances:", err)
+	}
+	if err := backfillTradeSeqs(); err != nil {
+		log.Fatal("Failed to backfill trade sequence numbers:", err)
+	}
+	if err := enforceAppendOnly("trades", "liquidity_events"); err != nil {
+		log.Fatal("Failed to make event logs append-only:", err)
+	}
+}
+
+// legacyUnitColumns are the columns that held floats in SOL or whole tokens, with
+// the scale of the base units they hold now and how a fraction of a unit rounds
+var legacyUnitColumns = []struct {
+	Table, Column string
+	Scale         int64
+	Round         string
+}{
+	{"coins", "market_cap", units.LamportsPerSol, "ROUND"},
+	{"coins", "total_supply", units.TokenUnit, "FLOOR"},
+	{"coins", "price", units.LamportsPerSol * units.PriceScale, "ROUND"},
+	{"coins", "base_price", units.LamportsPerSol * units.PriceScale, "ROUND"},
+	{"coins", "max_supply", units.TokenUnit, "FLOOR"},
+	{"coins", "target_mcap", units.LamportsPerSol, "ROUND"},
+	{"coins", "locked_amount", units.LamportsPerSol, "ROUND"},
+	{"trades", "amount", units.TokenUnit, "FLOOR"},
+	{"trades", "sol_amount", units.LamportsPerSol, "ROUND"},
+	{"trades", "price", units.LamportsPerSol * units.PriceScale, "ROUND"},
+	{"trades", "gas_fee", units.LamportsPerSol, "ROUND"},
+}
+
+// migrateLegacyUnits converts float columns from before base units to bigint,
+// rescaling the stored values. AutoMigrate would change the type without
+// rescaling, so this has to run first; afterwards the columns are bigint and it
+// does nothing.
+func migrateLegacyUnits() error {
+	return DB.Transaction(func(tx *gorm.DB) error {
+		for _, col := range legacyUnitColumns {
+			var dataType string
+			err := tx.Raw(`
+				SELECT data_type FROM information_schema.columns
+				WHERE table_schema = CURRENT_SCHEMA() AND table_name = ? AND column_name = ?
+			`, col.Table, col.Column).Scan(&dataType).Error
+			if err != nil {
+				return err
+			}
+			if dataType != "double precision" && dataType != "real" && dataType != "numeric" {
+				continue
+			}
+			log.Printf("Rescaling %s.%s to base units", col.Table, col.Column)
+			err = tx.Exec(fmt.Sprintf(
+				"ALTER TABLE %[1]s ALTER COLUMN %[2]s TYPE bigint USING %[3]s(%[2]s::numeric * %[4]d)::bigint",
+				col.Table, col.Column, col.Round, col.Scale,
+			)).Error
+			if err != nil {
+				return fmt.Errorf("%s.%s: %w", col.Table, col.Column, err)
+			}
+		}
+		return nil
+	})
+}
+
+// enforceAppendOnly installs triggers rejecting updates and deletes on the event
+// log tables coin state is folded from. Backfills of those tables must run before.
+func enforceAppendOnly(tables ...string) error {
+	err := DB.Exec(`
+		CREATE OR REPLACE FUNCTION reject_log_change() RETURNS trigger AS $$
+		BEGIN
+			RAISE EXCEPTION '% is append-only', TG_TABLE_NAME;
+		END;
+		$$ LANGUAGE plpgsql
+	`).Error
+	if err != nil {
+		return err
+	}
+	for _, table := range tables {
+		err := DB.Exec(fmt.Sprintf(`
+			CREATE OR REPLACE TRIGGER %[1]s_append_only BEFORE UPDATE OR DELETE ON %[1]s
+			FOR EACH ROW EXECUTE FUNCTION reject_log_change()
+		`, table)).Error
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// backfillBalances builds the balance ledger from trade history the first time it is empty
+func backfillBalances() error {
+	var count int64
+	if err := DB.Model(&models.Balance{}).Count(&count).Error; err != nil || count > 0 {
+		return err
+	}
+
+	err := DB.Exec(`
+		INSERT INTO balances (wallet, coin_id, user_id, amount, cost_basis, updated_at)
+		SELECT t.wallet, t.coin_id, COALESCE(MAX(u.id), ''),
+			SUM(CASE WHEN t.type = 'buy' THEN t.amount ELSE -t.amount END),
+			GREATEST(SUM(CASE WHEN t.type = 'buy' THEN t.sol_amount ELSE -t.sol_amount END), 0),
+			NOW()
+		FROM trades t
+		LEFT JOIN users u ON u.username = t.username
+		GROUP BY t.wallet, t.coin_id
+		HAVING SUM(CASE WHEN t.type = 'buy' THEN t.amount ELSE -t.amount END) > 0
+	`).Error
+	if err != nil {
+		return err
+	}
+
+	return DB.Exec(`
+		UPDATE coins SET holders = (
+			SELECT COUNT(*) FROM balances WHERE balances.coin_id = coins.id AND balances.amount > 0
+		)
+	`).Error
+}
+
+// backfillCreatorUserIDs attributes coins and creator fees recorded by username
+// to the users holding those names when the column is added. It runs once, so
+// a name registered later never inherits them; names nobody holds stay unclaimed.
+func backfillCreatorUserIDs() error {
+	return DB.Transaction(func(tx *gorm.DB) error {
+		err := tx.Exec(`
+			UPDATE coins SET creator_user_id = users.id
+			FROM users
+			WHERE users.username = coins.creator AND COALESCE(coins.creator_user_id, '') = ''
+		`).Error
+		if err != nil {
+			return err
+		}
+		if !tx.Migrator().HasColumn("fee_entries", "creator") {
+			return nil
+		}
+		return tx.Exec(`
+			UPDATE fee_entries SET creator_user_id = users.id
+			FROM users
+			WHERE users.username = fee_entries.creator AND COALESCE(fee_entries.creator_user_id, '') = ''
+		`).Error
+	})
+}
+
+// backfillTradeSeqs numbers trades stored without a sequence number, by a fresh
+// install's first start or by an older binary during a rolling deploy. They are
+// numbered in timestamp order after the coin's counter, which moves past them.
+func backfillTradeSeqs() error {
+	var count int64
+	if err := DB.Model(&models.Trade{}).Where("seq = 0").Count(&count).Error; err != nil || count == 0 {
+		return err
+	}
+
+	return DB.Transaction(func(tx *gorm.DB) error {
+		// Trades are append-only once the trigger exists; lift it for this
+		// transaction only, which also holds off inserts until it commits
+		var triggers int64
+		err := tx.Raw(`
+			SELECT COUNT(*) FROM pg_trigger
+			WHERE tgrelid = 'trades'::regclass AND tgname = 'trades_append_only'
+		`).Scan(&triggers).Error
+		if err != nil {
+			return err
+		}
+		if triggers > 0 {
+			if err := tx.Exec("ALTER TABLE trades DISABLE TRIGGER trades_append_only").Error; err != nil {
+				return err
+			}
+		}
+
+		// Lock the coins like a trade does, so live trades continue after the backfill
+		err = tx.Exec(`
+			SELECT id FROM coins WHERE id IN (SELECT coin_id FROM trades WHERE seq = 0)
+			ORDER BY id FOR UPDATE
+		`).Error
+		if err != nil {
+			return err
+		}
+		err = tx.Exec(`
+			UPDATE trades SET seq = numbered.seq
+			FROM (
+				SELECT t.id, c.trade_seq + ROW_NUMBER() OVER (PARTITION BY t.coin_id ORDER BY t.timestamp, t.id) AS seq
+				FROM trades t
+				JOIN coins c ON c.id = t.coin_id
+				WHERE t.seq = 0
+			) AS numbered
+			WHERE trades.id = numbered.id
+		`).Error
+		if err != nil {
+			return err
+		}
+		err = tx.Exec(`
+			UPDATE coins SET trade_seq = (
+				SELECT COALESCE(MAX(seq), 0) FROM trades WHERE trades.coin_id = coins.id
+			)
+			WHERE trade_seq < (SELECT COALESCE(MAX(seq), 0) FROM trades WHERE trades.coin_id = coins.id)
+		`).Error
+		if err != nil {
+			return err
+		}
+
+		if triggers > 0 {
+			return tx.Exec("ALTER TABLE trades ENABLE TRIGGER trades_append_only").Error
+		}
+		return nil
+	})
 }
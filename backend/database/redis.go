@@ -7,6 +7,8 @@ import (
 	"log"
 	"time"
 
+	"memepump/units"
+
 	"github.com/redis/go-redis/v9"
 )
 
@@ -44,25 +46,25 @@ func IsConnected() bool {
 // ========================================
 
 // CacheCoinPrice caches the current price for a coin
-func CacheCoinPrice(coinID string, price float64) error {
+func CacheCoinPrice(coinID string, price units.Price) error {
 	if RDB == nil {
 		return nil
 	}
 	key := fmt.Sprintf("coin:price:%s", coinID)
-	return RDB.Set(Ctx, key, price, 5*time.Second).Err()
+	return RDB.Set(Ctx, key, int64(price), 5*time.Second).Err()
 }
 
 // GetCachedPrice retrieves cached price, returns ok=false if not cached
-func GetCachedPrice(coinID string) (float64, bool) {
+func GetCachedPrice(coinID string) (units.Price, bool) {
 	if RDB == nil {
 		return 0, false
 	}
 	key := fmt.Sprintf("coin:price:%s", coinID)
-	val, err := RDB.Get(Ctx, key).Float64()
+	val, err := RDB.Get(Ctx, key).Int64()
 	if err != nil {
 		return 0, false
 	}
-	return val, true
+	return units.Price(val), true
 }
 
 // ========================================
@@ -125,9 +127,10 @@ func GetTrendingCoins(limit int) ([]string, error) {
 // ========================================
 
 type CachedHolder struct {
-	Address string  `json:"address"`
-	Amount  float64 `json:"amount"`
-	Percent float64 `json:"percent"`
+	Address string       `json:"address"`
+	Amount  units.Tokens `json:"amount"`
+	Percent float64      `json:"percent"`
+	AtCap   bool         `json:"atCap"` // The holder's wallets together reached the coin's holding cap
 }
 
 // CacheHolders caches holder list for a coin
@@ -160,17 +163,50 @@ func GetCachedHolders(coinID string) ([]CachedHolder, bool) {
 	return holders, true
 }
 
+// ========================================
+// Market Overview Caching (10 second TTL)
+// ========================================
+
+const marketOverviewKey = "market:overview"
+
+// CacheMarketOverview caches the market-wide overview
+func CacheMarketOverview(data interface{}) error {
+	if RDB == nil {
+		return nil
+	}
+	jsonData, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+	return RDB.Set(Ctx, marketOverviewKey, jsonData, 10*time.Second).Err()
+}
+
+// GetCachedMarketOverview retrieves the cached market overview
+func GetCachedMarketOverview(target interface{}) bool {
+	if RDB == nil {
+		return false
+	}
+	val, err := RDB.Get(Ctx, marketOverviewKey).Bytes()
+	if err != nil {
+		return false
+	}
+	if err := json.Unmarshal(val, target); err != nil {
+		return false
+	}
+	return true
+}
+
 // ========================================
 // Pub/Sub for Realtime Updates
 // ========================================
 
 // PublishPriceUpdate broadcasts a price update to all subscribers
-func PublishPriceUpdate(coinID string, price float64) error {
+func PublishPriceUpdate(coinID string, price units.Price) error {
 	if RDB == nil {
 		return nil
 	}
 	channel := fmt.Sprintf("price:%s", coinID)
-	return RDB.Publish(Ctx, channel, price).Err()
+	return RDB.Publish(Ctx, channel, int64(price)).Err()
 }
 
 // PublishTradeEvent broadcasts a trade event
@@ -187,8 +223,8 @@ func PublishTradeEvent(coinID string, tradeData interface{}) error {
 }
 
 // SubscribeToPriceUpdates returns a channel with price updates for a coin
-func SubscribeToPriceUpdates(ctx context.Context, coinID string) <-chan float64 {
-	out := make(chan float64)
+func SubscribeToPriceUpdates(ctx context.Context, coinID string) <-chan units.Price {
+	out := make(chan units.Price)
 	if RDB == nil {
 		close(out)
 		return out
@@ -208,9 +244,8 @@ func SubscribeToPriceUpdates(ctx context.Context, coinID string) <-chan float64
 				if !ok {
 					return
 				}
-				var price float64
-				if _, err := fmt.Sscanf(msg.Payload, "%f", &price); err == nil {
-					out <- price
+				if price, err := units.ParseInt(msg.Payload); err == nil {
+					out <- units.Price(price)
 				}
 			case <-ctx.Done():
 				return
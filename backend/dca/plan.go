@@ -0,0 +1,87 @@
+package dca
+
+import (
+	"errors"
+	"time"
+
+	"memepump/models"
+)
+
+// Plan statuses
+const (
+	StatusActive    = "active"
+	StatusPaused    = "paused"
+	StatusCancelled = "cancelled"
+	StatusCompleted = "completed"
+)
+
+// Run statuses
+const (
+	RunFilled = "filled"
+	RunFailed = "failed"
+)
+
+// Plan actions
+const (
+	ActionPause  = "pause"
+	ActionResume = "resume"
+	ActionCancel = "cancel"
+)
+
+// Plan limits
+const (
+	MinInterval = time.Minute
+	MaxRuns     = 10000
+)
+
+// Plan errors
+var (
+	ErrInvalidPlan   = errors.New("a plan needs a positive solAmount, an interval of at least a minute and 1 to 10000 runs")
+	ErrPlanNotFound  = errors.New("plan not found")
+	ErrInvalidAction = errors.New("plan cannot do that in its current status")
+)
+
+// Validate checks a new plan's amount, interval and run count
+func Validate(plan *models.DCAPlan) error {
+	if plan.SolAmount <= 0 || time.Duration(plan.Interval)*time.Second < MinInterval ||
+		plan.TotalRuns <= 0 || plan.TotalRuns > MaxRuns {
+		return ErrInvalidPlan
+	}
+	return nil
+}
+
+// Apply pauses, resumes or cancels a plan. A resumed plan that missed runs
+// while paused runs once right away rather than catching up.
+func Apply(plan *models.DCAPlan, action string, now time.Time) error {
+	switch {
+	case action == ActionPause && plan.Status == StatusActive:
+		plan.Status = StatusPaused
+	case action == ActionResume && plan.Status == StatusPaused:
+		plan.Status = StatusActive
+		if plan.NextRunAt.Before(now) {
+			plan.NextRunAt = now
+		}
+	case action == ActionCancel && (plan.Status == StatusActive || plan.Status == StatusPaused):
+		plan.Status = StatusCancelled
+	default:
+		return ErrInvalidAction
+	}
+	plan.UpdatedAt = now
+	return nil
+}
+
+// advance counts a recorded run and schedules the next one. Runs missed while
+// the backend was down are not bought in a burst; the schedule restarts from now.
+func advance(plan *models.DCAPlan, now time.Time) {
+	plan.RunsDone++
+	plan.UpdatedAt = now
+	if plan.RunsDone >= plan.TotalRuns {
+		plan.Status = StatusCompleted
+		return
+	}
+	interval := time.Duration(plan.Interval) * time.Second
+	plan.NextRunAt = plan.NextRunAt.Add(interval)
+	if !plan.NextRunAt.After(now) {
+		plan.NextRunAt = now.Add(interval)
+	}
+}
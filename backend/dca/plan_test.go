@@ -0,0 +1,74 @@
+package dca
+
+import (
+	"testing"
+	"time"
+
+	"memepump/models"
+	"memepump/units"
+)
+
+func TestAdvanceSchedulesAndCompletes(t *testing.T) {
+	start := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	plan := models.DCAPlan{Interval: 3600, TotalRuns: 3, Status: StatusActive, NextRunAt: start}
+
+	advance(&plan, start.Add(time.Second))
+	if plan.RunsDone != 1 || !plan.NextRunAt.Equal(start.Add(time.Hour)) {
+		t.Errorf("after run 1: done %d, next %v; want 1, %v", plan.RunsDone, plan.NextRunAt, start.Add(time.Hour))
+	}
+
+	// After a day of downtime the missed runs are not bought in a burst
+	late := start.Add(25 * time.Hour)
+	advance(&plan, late)
+	if !plan.NextRunAt.Equal(late.Add(time.Hour)) {
+		t.Errorf("next run after downtime = %v; want %v", plan.NextRunAt, late.Add(time.Hour))
+	}
+
+	advance(&plan, late.Add(time.Hour))
+	if plan.Status != StatusCompleted || plan.RunsDone != 3 {
+		t.Errorf("after the last run: %s with %d runs; want completed with 3", plan.Status, plan.RunsDone)
+	}
+}
+
+func TestApplyActions(t *testing.T) {
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	plan := models.DCAPlan{Status: StatusActive, NextRunAt: now.Add(-time.Hour)}
+
+	steps := []struct {
+		action string
+		status string
+		err    error
+	}{
+		{ActionResume, StatusActive, ErrInvalidAction},
+		{ActionPause, StatusPaused, nil},
+		{ActionPause, StatusPaused, ErrInvalidAction},
+		{ActionResume, StatusActive, nil},
+		{ActionCancel, StatusCancelled, nil},
+		{ActionResume, StatusCancelled, ErrInvalidAction},
+	}
+	for _, step := range steps {
+		if err := Apply(&plan, step.action, now); err != step.err || plan.Status != step.status {
+			t.Errorf("%s: status %s, err %v; want %s, %v", step.action, plan.Status, err, step.status, step.err)
+		}
+	}
+	if !plan.NextRunAt.Equal(now) {
+		t.Errorf("resumed plan runs at %v; want now", plan.NextRunAt)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	valid := models.DCAPlan{SolAmount: units.LamportsPerSol / 10, Interval: 3600, TotalRuns: 24}
+	if err := Validate(&valid); err != nil {
+		t.Errorf("hourly plan rejected: %v", err)
+	}
+	for _, plan := range []models.DCAPlan{
+		{SolAmount: 0, Interval: 3600, TotalRuns: 24},
+		{SolAmount: 1, Interval: 59, TotalRuns: 24},
+		{SolAmount: 1, Interval: 3600, TotalRuns: 0},
+		{SolAmount: 1, Interval: 3600, TotalRuns: MaxRuns + 1},
+	} {
+		if err := Validate(&plan); err != ErrInvalidPlan {
+			t.Errorf("Validate(%+v) = %v; want %v", plan, err, ErrInvalidPlan)
+		}
+	}
+}
@@ -0,0 +1,106 @@
+package dca
+
+import (
+	"context"
+	"errors"
+	"log"
+	"time"
+
+	"memepump/database"
+	"memepump/models"
+	"memepump/trading"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// PollInterval is how often the worker looks for due plans
+const PollInterval = 5 * time.Second
+
+// Run executes due plan runs until ctx is cancelled. onTrade is called with
+// each committed buy, as executeTrade does after its own trades.
+func Run(ctx context.Context, onTrade func(*trading.Result)) {
+	ticker := time.NewTicker(PollInterval)
+	defer ticker.Stop()
+
+	for {
+		for processNext(onTrade) {
+			// Run every due plan before waiting again
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// processNext runs the most overdue plan once and reports whether there was one.
+// The buy, the run record and the plan's schedule commit together, so a crash
+// either loses the whole run (which is then retried) or none of it.
+func processNext(onTrade func(*trading.Result)) bool {
+	now := time.Now()
+	var result *trading.Result
+
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		var plan models.DCAPlan
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+			Where("status = ? AND next_run_at <= ?", StatusActive, now).
+			Order("next_run_at").
+			First(&plan).Error
+		if err != nil {
+			return err
+		}
+
+		run := models.DCARun{
+			ID:        uuid.New().String(),
+			PlanID:    plan.ID,
+			Seq:       plan.RunsDone + 1,
+			CreatedAt: now,
+		}
+
+		// A savepoint lets a rejected buy roll back alone and still be recorded
+		tradeErr := tx.Transaction(func(trade *gorm.DB) error {
+			result, err = trading.Execute(trade, &models.TradeRequest{
+				CoinID:    plan.CoinID,
+				Type:      trading.SideBuy,
+				SolAmount: plan.SolAmount,
+				Wallet:    plan.Wallet,
+				Username:  plan.Username,
+				UserID:    plan.UserID,
+			})
+			return err
+		})
+		switch {
+		case errors.Is(tradeErr, trading.ErrStorage):
+			return tradeErr // Retried on the next poll
+		case tradeErr != nil:
+			result = nil
+			run.Status = RunFailed
+			run.Error = tradeErr.Error()
+		default:
+			run.Status = RunFilled
+			run.TradeID = result.Trade.ID
+		}
+
+		// The unique (plan, seq) index rejects a run that was already recorded
+		if err := tx.Create(&run).Error; err != nil {
+			return err
+		}
+		advance(&plan, now)
+		return tx.Save(&plan).Error
+	})
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return false
+	}
+	if err != nil {
+		log.Println("DCA run failed:", err)
+		return false
+	}
+
+	if result != nil && onTrade != nil {
+		onTrade(result)
+	}
+	return true
+}
@@ -0,0 +1,55 @@
+package dex
+
+import (
+	"context"
+	"errors"
+	"os"
+	"strings"
+
+	"memepump/units"
+)
+
+// ErrNotConfigured is returned by adapters whose relayer is not set up
+var ErrNotConfigured = errors.New("dex adapter not configured")
+
+// PoolRequest describes the liquidity a graduated coin seeds its DEX pool with
+type PoolRequest struct {
+	CoinID         string         `json:"coinId"`
+	Symbol         string         `json:"symbol"`
+	MintAddress    string         `json:"mintAddress"`
+	ChainID        string         `json:"chainId"`
+	SolLiquidity   units.Lamports `json:"solLiquidity"`
+	TokenLiquidity units.Tokens   `json:"tokenLiquidity"`
+}
+
+// Pool is a created DEX pool
+type Pool struct {
+	Address string `json:"poolAddress"`
+	TxHash  string `json:"txHash"`
+}
+
+// Adapter creates liquidity pools on one DEX
+type Adapter interface {
+	Name() string
+	CreatePool(ctx context.Context, req PoolRequest) (*Pool, error)
+}
+
+// ForChain picks the adapter for a coin's chain. DEX_ADAPTER=mock forces the
+// local mock; chains whose relayer is not configured also fall back to it.
+func ForChain(chainID string) Adapter {
+	if os.Getenv("DEX_ADAPTER") == "mock" {
+		return NewMockAdapter()
+	}
+
+	var adapter *RelayerAdapter
+	switch {
+	case chainID == "base" || chainID == "ethereum":
+		adapter = NewUniswapAdapter()
+	case chainID == "" || strings.HasPrefix(chainID, "solana"):
+		adapter = NewRaydiumAdapter()
+	}
+	if adapter == nil || !adapter.IsConfigured() {
+		return NewMockAdapter()
+	}
+	return adapter
+}
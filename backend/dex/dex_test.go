@@ -0,0 +1,35 @@
+package dex
+
+import (
+	"context"
+	"testing"
+)
+
+func TestForChainFallsBackToMock(t *testing.T) {
+	t.Setenv("RAYDIUM_RELAYER_URL", "")
+	t.Setenv("UNISWAP_RELAYER_URL", "")
+
+	for _, chain := range []string{"", "solana-devnet", "base", "unknown"} {
+		if name := ForChain(chain).Name(); name != "mock" {
+			t.Errorf("ForChain(%q) = %s; want mock without relayers", chain, name)
+		}
+	}
+
+	t.Setenv("RAYDIUM_RELAYER_URL", "http://relayer.local")
+	if name := ForChain("solana-mainnet").Name(); name != "raydium" {
+		t.Errorf("ForChain(solana-mainnet) = %s; want raydium", name)
+	}
+	if name := ForChain("base").Name(); name != "mock" {
+		t.Errorf("ForChain(base) = %s; want mock without a Uniswap relayer", name)
+	}
+}
+
+func TestMockPoolsAreStable(t *testing.T) {
+	m := NewMockAdapter()
+	a, _ := m.CreatePool(context.Background(), PoolRequest{CoinID: "coin-1"})
+	b, _ := m.CreatePool(context.Background(), PoolRequest{CoinID: "coin-1"})
+	c, _ := m.CreatePool(context.Background(), PoolRequest{CoinID: "coin-2"})
+	if a.Address != b.Address || a.Address == c.Address {
+		t.Errorf("pool addresses %s, %s, %s; want stable per coin", a.Address, b.Address, c.Address)
+	}
+}
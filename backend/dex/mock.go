@@ -0,0 +1,28 @@
+package dex
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+)
+
+// MockAdapter creates deterministic fake pools for local development
+type MockAdapter struct{}
+
+// NewMockAdapter returns the local mock adapter
+func NewMockAdapter() *MockAdapter {
+	return &MockAdapter{}
+}
+
+func (m *MockAdapter) Name() string {
+	return "mock"
+}
+
+// CreatePool derives a stable pool address from the coin ID
+func (m *MockAdapter) CreatePool(ctx context.Context, req PoolRequest) (*Pool, error) {
+	sum := sha256.Sum256([]byte("pool:" + req.CoinID))
+	return &Pool{
+		Address: "mockpool" + hex.EncodeToString(sum[:16]),
+		TxHash:  "mocktx" + hex.EncodeToString(sum[16:]),
+	}, nil
+}
@@ -0,0 +1,95 @@
+package dex
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"time"
+)
+
+// RelayerAdapter creates pools through a signing relayer service that holds
+// the migration keys and talks to the DEX program or router
+type RelayerAdapter struct {
+	name       string
+	url        string
+	apiKey     string
+	httpClient *http.Client
+}
+
+// NewRaydiumAdapter migrates Solana coins to Raydium via RAYDIUM_RELAYER_URL
+func NewRaydiumAdapter() *RelayerAdapter {
+	return newRelayerAdapter("raydium", os.Getenv("RAYDIUM_RELAYER_URL"))
+}
+
+// NewUniswapAdapter migrates EVM coins to Uniswap via UNISWAP_RELAYER_URL
+func NewUniswapAdapter() *RelayerAdapter {
+	return newRelayerAdapter("uniswap", os.Getenv("UNISWAP_RELAYER_URL"))
+}
+
+func newRelayerAdapter(name, url string) *RelayerAdapter {
+	return &RelayerAdapter{
+		name:   name,
+		url:    url,
+		apiKey: os.Getenv("DEX_RELAYER_API_KEY"),
+		httpClient: &http.Client{
+			Timeout: 60 * time.Second,
+		},
+	}
+}
+
+// IsConfigured returns true if the relayer URL is set
+func (a *RelayerAdapter) IsConfigured() bool {
+	return a.url != ""
+}
+
+func (a *RelayerAdapter) Name() string {
+	return a.name
+}
+
+// CreatePool asks the relayer to create and seed the pool
+func (a *RelayerAdapter) CreatePool(ctx context.Context, req PoolRequest) (*Pool, error) {
+	if !a.IsConfigured() {
+		return nil, fmt.Errorf("%w: %s", ErrNotConfigured, a.name)
+	}
+
+	body, err := json.Marshal(req)
+	if err != nil {
+		return nil, err
+	}
+
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, a.url+"/pools", bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+	if a.apiKey != "" {
+		httpReq.Header.Set("Authorization", "Bearer "+a.apiKey)
+	}
+
+	resp, err := a.httpClient.Do(httpReq)
+	if err != nil {
+		return nil, fmt.Errorf("%s relayer request failed: %w", a.name, err)
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusCreated {
+		return nil, fmt.Errorf("%s relayer error (%d): %s", a.name, resp.StatusCode, string(respBody))
+	}
+
+	var pool Pool
+	if err := json.Unmarshal(respBody, &pool); err != nil {
+		return nil, fmt.Errorf("failed to parse %s relayer response: %w", a.name, err)
+	}
+	if pool.Address == "" {
+		return nil, fmt.Errorf("%s relayer returned no pool address", a.name)
+	}
+	return &pool, nil
+}
@@ -0,0 +1,163 @@
+package graduation
+
+import (
+	"context"
+	"errors"
+	"log"
+	"time"
+
+	"memepump/database"
+	"memepump/dex"
+	"memepump/models"
+	"memepump/realtime"
+	"memepump/trading"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Worker tuning
+const (
+	PollInterval = 5 * time.Second
+	MaxAttempts  = 6
+	leaseTime    = 2 * time.Minute // A running job is retried if its worker disappears
+	retryBackoff = 30 * time.Second
+)
+
+// AdapterFor picks the DEX adapter for a coin; replaceable in tests
+var AdapterFor = func(coin *models.Coin) dex.Adapter {
+	return dex.ForChain(coin.ChainID)
+}
+
+// Run processes migration jobs until ctx is cancelled
+func Run(ctx context.Context) {
+	ticker := time.NewTicker(PollInterval)
+	defer ticker.Stop()
+
+	for {
+		for processNext(ctx) {
+			// Drain the queue before waiting again
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// processNext runs one due job and reports whether there was one
+func processNext(ctx context.Context) bool {
+	job, coin, err := claimJob(time.Now())
+	if err != nil {
+		log.Println("Migration queue error:", err)
+		return false
+	}
+	if job == nil {
+		return false
+	}
+
+	adapter := AdapterFor(coin)
+	callCtx, cancel := context.WithTimeout(ctx, leaseTime/2)
+	pool, err := adapter.CreatePool(callCtx, dex.PoolRequest{
+		CoinID:         coin.ID,
+		Symbol:         coin.Symbol,
+		MintAddress:    coin.MintAddress,
+		ChainID:        coin.ChainID,
+		SolLiquidity:   job.SolLiquidity,
+		TokenLiquidity: job.TokenLiquidity,
+	})
+	cancel()
+
+	if err != nil {
+		failJob(job, err)
+		return true
+	}
+	completeJob(job, adapter.Name(), pool)
+	return true
+}
+
+// claimJob leases the oldest due job so other workers skip it. A running job
+// whose lease expired on its last attempt is failed instead of leased again.
+func claimJob(now time.Time) (*models.MigrationJob, *models.Coin, error) {
+	var job models.MigrationJob
+	var coin models.Coin
+
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		expired := tx.Model(&models.MigrationJob{}).
+			Where("status = ? AND next_attempt_at <= ? AND attempts >= ?", trading.MigrationRunning, now, MaxAttempts).
+			Updates(map[string]interface{}{"status": trading.MigrationFailed, "last_error": "worker lease expired on the last attempt"})
+		if expired.Error != nil {
+			return expired.Error
+		}
+		if expired.RowsAffected > 0 {
+			log.Printf("Gave up on %d migrations whose worker disappeared", expired.RowsAffected)
+		}
+
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+			Where("status IN ? AND next_attempt_at <= ?", []string{trading.MigrationPending, trading.MigrationRunning}, now).
+			Order("next_attempt_at").
+			First(&job).Error
+		if err != nil {
+			return err
+		}
+		if err := tx.First(&coin, "id = ?", job.CoinID).Error; err != nil {
+			return err
+		}
+
+		job.Status = trading.MigrationRunning
+		job.Attempts++
+		job.NextAttemptAt = now.Add(leaseTime)
+		return tx.Save(&job).Error
+	})
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil, nil
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	return &job, &coin, nil
+}
+
+// completeJob stores the pool on the coin and announces the migration
+func completeJob(job *models.MigrationJob, adapterName string, pool *dex.Pool) {
+	job.Status = trading.MigrationDone
+	job.Adapter = adapterName
+	job.PoolAddress = pool.Address
+	job.TxHash = pool.TxHash
+	job.LastError = ""
+
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Save(job).Error; err != nil {
+			return err
+		}
+		return tx.Model(&models.Coin{}).Where("id = ?", job.CoinID).Update("pool_address", pool.Address).Error
+	})
+	if err != nil {
+		// The lease expires and the job runs again; adapters must tolerate a repeat
+		log.Println("Failed to store migration result:", err)
+		return
+	}
+
+	realtime.BroadcastSafe("coinMigrated", map[string]interface{}{
+		"coinId":      job.CoinID,
+		"poolAddress": pool.Address,
+		"dex":         adapterName,
+	})
+}
+
+// failJob schedules a retry with backoff, or gives up after MaxAttempts
+func failJob(job *models.MigrationJob, cause error) {
+	log.Printf("Migration of coin %s failed (attempt %d): %v", job.CoinID, job.Attempts, cause)
+
+	job.LastError = cause.Error()
+	if job.Attempts >= MaxAttempts {
+		job.Status = trading.MigrationFailed
+	} else {
+		job.Status = trading.MigrationPending
+		job.NextAttemptAt = time.Now().Add(retryBackoff << (job.Attempts - 1))
+	}
+	if err := database.DB.Save(job).Error; err != nil {
+		log.Println("Failed to store migration failure:", err)
+	}
+}
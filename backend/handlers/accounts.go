@@ -0,0 +1,208 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"log"
+	"net/http"
+	"os"
+	"strconv"
+	"time"
+
+	"memepump/database"
+	"memepump/models"
+	"memepump/trading"
+	"memepump/units"
+
+	"github.com/gin-gonic/gin"
+)
+
+// SOL account funding modes
+const (
+	AccountModeSimulated = "simulated" // Deposits are credited as requested, withdrawals complete instantly
+	AccountModeSolana    = "solana"    // Deposits are verified on chain; withdrawals are not paid out yet
+)
+
+// MaxSimulatedDeposit caps a single simulated deposit
+const MaxSimulatedDeposit = units.Lamports(1000 * units.LamportsPerSol)
+
+// SOL account configuration, set by InitClients
+var (
+	accountMode    string
+	treasuryWallet string // Receives on-chain deposits in solana mode
+)
+
+func initAccounts() {
+	accountMode = os.Getenv("SOL_ACCOUNT_MODE")
+	if accountMode == "" {
+		accountMode = AccountModeSimulated
+	}
+	treasuryWallet = os.Getenv("TREASURY_WALLET")
+	if accountMode == AccountModeSolana && treasuryWallet == "" {
+		log.Println("SOL_ACCOUNT_MODE=solana without TREASURY_WALLET, deposits will be rejected")
+	}
+}
+
+type DepositRequest struct {
+	Amount    units.Lamports `json:"amount"`    // Simulated mode only
+	Signature string         `json:"signature"` // Solana mode: transfer to the treasury wallet
+}
+
+type WithdrawRequest struct {
+	Amount  units.Lamports `json:"amount" binding:"required"`
+	Address string         `json:"address"` // Defaults to the primary Solana wallet
+}
+
+// ========================================
+// SOL Account Handlers
+// ========================================
+
+// GetAccount returns the caller's SOL balance
+func GetAccount(c *gin.Context) {
+	userID := c.GetString("userID")
+
+	balance, err := trading.SolBalance(database.DB, userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load account"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"userId":  userID,
+		"balance": balance,
+		"mode":    accountMode,
+	})
+}
+
+// Deposit credits the caller's SOL account
+func Deposit(c *gin.Context) {
+	userID := c.GetString("userID")
+
+	var req DepositRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	entry := models.SolTransaction{Kind: trading.SolTxDeposit}
+	switch accountMode {
+	case AccountModeSolana:
+		if req.Signature == "" || treasuryWallet == "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Deposits require the signature of a transfer to the treasury wallet"})
+			return
+		}
+		ctx, cancel := context.WithTimeout(c.Request.Context(), 15*time.Second)
+		defer cancel()
+		received, sender, err := solanaClient.GetReceivedLamports(ctx, req.Signature, treasuryWallet)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Deposit not confirmed: " + err.Error()})
+			return
+		}
+		if received <= 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Transaction does not transfer SOL to the treasury wallet"})
+			return
+		}
+		// Only the owner of the sending wallet may claim the transfer
+		var link models.WalletLink
+		if err := database.DB.First(&link, "address = ? AND user_id = ?", sender, userID).Error; err != nil {
+			c.JSON(http.StatusForbidden, gin.H{"error": "Deposits must be sent from a wallet linked to your account"})
+			return
+		}
+		entry.Amount = received
+		entry.Signature = req.Signature
+		entry.Address = sender
+	default:
+		if req.Amount <= 0 || req.Amount > MaxSimulatedDeposit {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "amount must be positive and at most " + strconv.FormatInt(int64(MaxSimulatedDeposit), 10) + " lamports"})
+			return
+		}
+		entry.Amount = req.Amount
+	}
+
+	// Reject replayed signatures up front; the unique index catches concurrent ones
+	if req.Signature != "" {
+		var count int64
+		database.DB.Model(&models.SolTransaction{}).Where("signature = ?", req.Signature).Count(&count)
+		if count > 0 {
+			c.JSON(http.StatusConflict, gin.H{"error": "Deposit already credited"})
+			return
+		}
+	}
+
+	postSolTransaction(c, userID, entry, http.StatusCreated)
+}
+
+// Withdraw debits the caller's SOL account. Solana mode has no treasury payout
+// yet, so withdrawals are refused there rather than debited and left pending.
+func Withdraw(c *gin.Context) {
+	userID := c.GetString("userID")
+
+	if accountMode == AccountModeSolana {
+		c.JSON(http.StatusNotImplemented, gin.H{"error": "Withdrawals are not available for on-chain accounts yet"})
+		return
+	}
+
+	var req WithdrawRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if req.Amount <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "amount must be positive"})
+		return
+	}
+
+	address := req.Address
+	if address == "" {
+		var primary models.WalletLink
+		if err := database.DB.Where("user_id = ? AND chain = ? AND is_primary = ?", userID, "solana", true).First(&primary).Error; err == nil {
+			address = primary.Address
+		}
+	}
+
+	entry := models.SolTransaction{Kind: trading.SolTxWithdrawal, Amount: -req.Amount, Address: address}
+	postSolTransaction(c, userID, entry, http.StatusCreated)
+}
+
+// GetAccountTransactions returns the caller's SOL account history, newest first
+func GetAccountTransactions(c *gin.Context) {
+	userID := c.GetString("userID")
+
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "50"))
+	if err != nil || limit <= 0 || limit > 200 {
+		limit = 50
+	}
+
+	var transactions []models.SolTransaction
+	if err := database.DB.Where("user_id = ?", userID).
+		Order("created_at DESC").
+		Limit(limit).
+		Find(&transactions).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load transactions"})
+		return
+	}
+
+	c.JSON(http.StatusOK, transactions)
+}
+
+// postSolTransaction applies an account entry in its own transaction and writes the response
+func postSolTransaction(c *gin.Context, userID string, entry models.SolTransaction, status int) {
+	tx := database.DB.Begin()
+	posted, err := trading.PostSolTransaction(tx, userID, entry)
+	if err != nil {
+		tx.Rollback()
+		if errors.Is(err, trading.ErrInsufficientFunds) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error(), "code": "INSUFFICIENT_FUNDS"})
+			return
+		}
+		log.Println("SOL account update failed:", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update account"})
+		return
+	}
+	if err := tx.Commit().Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update account"})
+		return
+	}
+
+	c.JSON(status, posted)
+}
@@ -0,0 +1,96 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"memepump/database"
+	"memepump/models"
+	"memepump/trading"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Candle window limits
+const (
+	DefaultCandles = 300
+	MaxCandles     = 1500
+)
+
+// ========================================
+// Candle Handlers
+// ========================================
+
+// GetCandles returns a coin's OHLCV candles for an interval, oldest first.
+// from and to take Unix seconds or RFC 3339 and default to the latest 300 candles;
+// fill=true repeats the close across intervals without trades.
+func GetCandles(c *gin.Context) {
+	interval, ok := trading.LookupCandleInterval(c.DefaultQuery("interval", "1m"))
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "interval must be one of 1s, 1m, 5m, 15m, 1h, 4h, 1d"})
+		return
+	}
+
+	to := time.Now()
+	if v := c.Query("to"); v != "" {
+		t, err := parseTimeParam(v)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid to"})
+			return
+		}
+		to = t
+	}
+	from := to.Add(-DefaultCandles * interval.Duration)
+	if v := c.Query("from"); v != "" {
+		t, err := parseTimeParam(v)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid from"})
+			return
+		}
+		from = t
+	}
+	if !from.Before(to) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "from must be before to"})
+		return
+	}
+	if to.Sub(from) > MaxCandles*interval.Duration {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "range spans more than " + strconv.Itoa(MaxCandles) + " candles"})
+		return
+	}
+
+	coinID := c.Param("id")
+	var count int64
+	if database.DB.Model(&models.Coin{}).Where("id = ?", coinID).Count(&count); count == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Coin not found"})
+		return
+	}
+
+	candles := []models.Candle{}
+	if err := database.DB.
+		Where("coin_id = ? AND period = ? AND start >= ? AND start < ?",
+			coinID, interval.Name, trading.CandleStart(from, interval.Duration), to).
+		Order("start").Find(&candles).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load candles"})
+		return
+	}
+	if c.Query("fill") == "true" {
+		candles = trading.FillCandleGaps(candles, interval.Duration)
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"coinId":   coinID,
+		"interval": interval.Name,
+		"from":     from,
+		"to":       to,
+		"candles":  candles,
+	})
+}
+
+// parseTimeParam reads Unix seconds or an RFC 3339 time
+func parseTimeParam(v string) (time.Time, error) {
+	if seconds, err := strconv.ParseInt(v, 10, 64); err == nil {
+		return time.Unix(seconds, 0), nil
+	}
+	return time.Parse(time.RFC3339, v)
+}
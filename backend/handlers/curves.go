@@ -0,0 +1,92 @@
+package handlers
+
+import (
+	"net/http"
+
+	"memepump/blockchain"
+	"memepump/models"
+	"memepump/units"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Curve preview sizes
+const (
+	DefaultDesignPoints = 50
+	MaxDesignPoints     = 500
+)
+
+// DesignCurveRequest describes a curve in launch terms, in SOL and whole tokens
+type DesignCurveRequest struct {
+	Type             string  `json:"type"`             // exponential (default), linear, constant_product or virtual_amm
+	StartPrice       float64 `json:"startPrice"`       // SOL per token at launch
+	TargetMcap       float64 `json:"targetMcap"`       // SOL market cap to graduate at
+	TargetMcapUSD    float64 `json:"targetMcapUsd"`    // Or a USD market cap, with solPriceUsd
+	SolPriceUSD      float64 `json:"solPriceUsd"`      // USD per SOL for targetMcapUsd
+	GraduationSupply float64 `json:"graduationSupply"` // Tokens sold when the target is reached
+	MaxSupply        float64 `json:"maxSupply"`        // Optional, solved for constant_product and virtual_amm
+	Points           int     `json:"points"`           // Preview points, default 50
+}
+
+// ========================================
+// Curve Designer
+// ========================================
+
+// DesignCurve solves curve parameters from launch targets and previews the curve.
+// The returned curve can be passed as is to coin creation.
+func DesignCurve(c *gin.Context) {
+	var req DesignCurveRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	targetMcap := req.TargetMcap
+	if req.TargetMcapUSD > 0 {
+		if req.TargetMcap > 0 || req.SolPriceUSD <= 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "targetMcapUsd needs solPriceUsd and replaces targetMcap"})
+			return
+		}
+		targetMcap = req.TargetMcapUSD / req.SolPriceUSD
+	}
+	if req.Points == 0 {
+		req.Points = DefaultDesignPoints
+	}
+	if req.Points < 2 || req.Points > MaxDesignPoints {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "points must be between 2 and 500"})
+		return
+	}
+
+	target := blockchain.DesignTarget{
+		Type:             blockchain.CurveType(req.Type),
+		StartPrice:       units.PriceFromSol(req.StartPrice),
+		TargetMcap:       units.LamportsFromSol(targetMcap),
+		GraduationSupply: units.TokensFromFloat(req.GraduationSupply),
+		MaxSupply:        units.TokensFromFloat(req.MaxSupply),
+	}
+	curve, err := blockchain.DesignCurve(target)
+	if err != nil {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error(), "designable": blockchain.DesignableCurves})
+		return
+	}
+
+	spec := curve.Curve.Spec()
+	graduationPrice := curve.CalculatePrice(target.GraduationSupply)
+	c.JSON(http.StatusOK, gin.H{
+		"curve": models.CurveParams{
+			Type:       string(spec.Type),
+			K:          spec.K,
+			Slope:      spec.Slope,
+			Params:     spec.Params,
+			BasePrice:  spec.BasePrice,
+			MaxSupply:  spec.MaxSupply,
+			TargetMcap: curve.TargetMcap,
+		},
+		"startPrice":       curve.CalculatePrice(0),
+		"graduationSupply": target.GraduationSupply,
+		"graduationPrice":  graduationPrice,
+		"graduationMcap":   units.Value(target.GraduationSupply, graduationPrice),
+		"raised":           curve.CalculateBuyPrice(0, target.GraduationSupply), // SOL on the curve at graduation
+		"points":           curve.GetCurveDataPoints(0, req.Points),
+	})
+}
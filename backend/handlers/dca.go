@@ -0,0 +1,147 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"memepump/database"
+	"memepump/dca"
+	"memepump/models"
+	"memepump/units"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type CreateDCAPlanRequest struct {
+	CoinID    string         `json:"coinId" binding:"required"`
+	Wallet    string         `json:"wallet" binding:"required"`
+	SolAmount units.Lamports `json:"solAmount"`       // Spent per run, fees included
+	Interval  int64          `json:"intervalSeconds"` // Seconds between runs
+	TotalRuns int            `json:"totalRuns"`
+	StartAt   *time.Time     `json:"startAt"` // First run, defaults to now
+}
+
+// ========================================
+// DCA Plan Handlers
+// ========================================
+
+// CreateDCAPlan schedules recurring buys for the caller
+func CreateDCAPlan(c *gin.Context) {
+	userID := c.GetString("userID")
+
+	var req CreateDCAPlanRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var user models.User
+	if err := database.DB.First(&user, "id = ?", userID).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		return
+	}
+	var coin models.Coin
+	if err := database.DB.Select("id").First(&coin, "id = ?", req.CoinID).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Coin not found"})
+		return
+	}
+	var link models.WalletLink
+	if err := database.DB.First(&link, "address = ? AND user_id = ?", req.Wallet, userID).Error; err != nil {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Wallet is not linked to your account"})
+		return
+	}
+
+	now := time.Now()
+	plan := models.DCAPlan{
+		ID:        uuid.New().String(),
+		UserID:    userID,
+		Username:  user.Username,
+		CoinID:    coin.ID,
+		Wallet:    req.Wallet,
+		SolAmount: req.SolAmount,
+		Interval:  req.Interval,
+		TotalRuns: req.TotalRuns,
+		Status:    dca.StatusActive,
+		NextRunAt: now,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+	if req.StartAt != nil && req.StartAt.After(now) {
+		plan.NextRunAt = *req.StartAt
+	}
+	if err := dca.Validate(&plan); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := database.DB.Create(&plan).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create plan"})
+		return
+	}
+	c.JSON(http.StatusCreated, plan)
+}
+
+// GetDCAPlans returns the caller's plans, newest first
+func GetDCAPlans(c *gin.Context) {
+	var plans []models.DCAPlan
+	if err := database.DB.Where("user_id = ?", c.GetString("userID")).Order("created_at DESC").Find(&plans).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load plans"})
+		return
+	}
+	c.JSON(http.StatusOK, plans)
+}
+
+// GetDCAPlan returns one of the caller's plans with its runs
+func GetDCAPlan(c *gin.Context) {
+	var plan models.DCAPlan
+	if err := database.DB.First(&plan, "id = ? AND user_id = ?", c.Param("id"), c.GetString("userID")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": dca.ErrPlanNotFound.Error()})
+		return
+	}
+
+	var runs []models.DCARun
+	database.DB.Where("plan_id = ?", plan.ID).Order("seq DESC").Find(&runs)
+
+	c.JSON(http.StatusOK, gin.H{
+		"plan": plan,
+		"runs": runs,
+	})
+}
+
+// PauseDCAPlan, ResumeDCAPlan and CancelDCAPlan change a plan's status
+func PauseDCAPlan(c *gin.Context)  { applyDCAAction(c, dca.ActionPause) }
+func ResumeDCAPlan(c *gin.Context) { applyDCAAction(c, dca.ActionResume) }
+func CancelDCAPlan(c *gin.Context) { applyDCAAction(c, dca.ActionCancel) }
+
+func applyDCAAction(c *gin.Context, action string) {
+	var plan models.DCAPlan
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		// Waits for a run in progress, so a cancelled plan never buys again
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			First(&plan, "id = ? AND user_id = ?", c.Param("id"), c.GetString("userID")).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return dca.ErrPlanNotFound
+			}
+			return err
+		}
+		if err := dca.Apply(&plan, action, time.Now()); err != nil {
+			return err
+		}
+		return tx.Save(&plan).Error
+	})
+
+	switch {
+	case err == nil:
+		c.JSON(http.StatusOK, plan)
+	case errors.Is(err, dca.ErrPlanNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case errors.Is(err, dca.ErrInvalidAction):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error(), "status": plan.Status})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update plan"})
+	}
+}
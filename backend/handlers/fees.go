@@ -0,0 +1,73 @@
+package handlers
+
+import (
+	"errors"
+	"log"
+	"net/http"
+
+	"memepump/database"
+	"memepump/models"
+	"memepump/trading"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ========================================
+// Creator Fee Handlers
+// ========================================
+
+// ClaimCreatorFees moves the creator fees accrued on the caller's coins into their SOL account
+func ClaimCreatorFees(c *gin.Context) {
+	userID := c.GetString("userID") // From auth middleware
+
+	var user models.User
+	if err := database.DB.First(&user, "id = ?", userID).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		return
+	}
+
+	tx := database.DB.Begin()
+	claim, err := trading.ClaimCreatorFees(tx, user.ID)
+	if err != nil {
+		tx.Rollback()
+		if errors.Is(err, trading.ErrNothingToClaim) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		log.Println("Fee claim failed:", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to claim fees"})
+		return
+	}
+	if err := tx.Commit().Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to claim fees"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"claimed": -claim.Amount,
+		"claim":   claim,
+	})
+}
+
+// GetCreatorFees returns the caller's unclaimed creator fees
+func GetCreatorFees(c *gin.Context) {
+	userID := c.GetString("userID")
+
+	var user models.User
+	if err := database.DB.First(&user, "id = ?", userID).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		return
+	}
+
+	claimable, err := trading.ClaimableCreatorFees(database.DB, user.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load fees"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"creator":   user.Username,
+		"claimable": claimable,
+		"schedule":  trading.Fees,
+	})
+}
@@ -4,11 +4,15 @@ import (
 	"encoding/base64"
 	"io"
 	"net/http"
+	"time"
 
 	"memepump/blockchain"
 	"memepump/database"
 	"memepump/ipfs"
+	"memepump/market"
 	"memepump/models"
+	"memepump/trading"
+	"memepump/units"
 
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
@@ -24,6 +28,7 @@ var (
 func InitClients() {
 	ipfsClient = ipfs.NewClient()
 	solanaClient = blockchain.NewSolanaClient()
+	initAccounts()
 }
 
 // ========================================
@@ -229,27 +234,110 @@ func GetCurveData(c *gin.Context) {
 	}
 
 	// Create bonding curve from coin parameters
-	curve := blockchain.NewFromParams(
-		coin.CurveType,
-		coin.CurveK,
-		coin.CurveSlope,
-		coin.BasePrice,
-		1000000000, // Max supply
-		100000,     // Target market cap
-	)
+	curve, err := trading.CurveForCoin(&coin)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Invalid coin curve"})
+		return
+	}
 
 	// Get curve data points for visualization
 	points := curve.GetCurveDataPoints(coin.TotalSupply, 100)
 
 	c.JSON(http.StatusOK, gin.H{
-		"curveType":     coin.CurveType,
+		"curveType":     curve.CurveType(),
+		"params":        curve.Curve.Spec(),
+		"maxSupply":     curve.MaxSupply,
+		"targetMcap":    curve.TargetMcap,
 		"currentSupply": coin.TotalSupply,
 		"currentPrice":  coin.Price,
 		"progress":      coin.Progress,
-		"points":        points,
+		"reserves": gin.H{
+			"realSol":      coin.RealSolReserves,
+			"realToken":    coin.RealTokenReserves,
+			"virtualSol":   coin.VirtualSolReserves,
+			"virtualToken": coin.VirtualTokenReserves,
+		},
+		"points": points,
 	})
 }
 
+// GetMigration returns the DEX migration job of a graduated coin
+func GetMigration(c *gin.Context) {
+	var job models.MigrationJob
+	if err := database.DB.First(&job, "coin_id = ?", c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Coin has not graduated"})
+		return
+	}
+	c.JSON(http.StatusOK, job)
+}
+
+// GetQuote previews a trade on the coin's bonding curve without executing it.
+// Pass amount (token base units) for buys or sells, or solAmount (lamports) for a SOL-in buy.
+func GetQuote(c *gin.Context) {
+	coinID := c.Param("id")
+	side := c.DefaultQuery("side", trading.SideBuy)
+
+	var coin models.Coin
+	if err := database.DB.First(&coin, "id = ?", coinID).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Coin not found"})
+		return
+	}
+
+	amountParam, solParam := c.Query("amount"), c.Query("solAmount")
+	if (amountParam == "") == (solParam == "") {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Provide either amount or solAmount"})
+		return
+	}
+	if solParam != "" && side != trading.SideBuy {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "solAmount is only supported for buys"})
+		return
+	}
+
+	var amount, solAmount int64
+	var err error
+	if solParam != "" {
+		if solAmount, err = units.ParseInt(solParam); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid solAmount"})
+			return
+		}
+	} else if amount, err = units.ParseInt(amountParam); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid amount"})
+		return
+	}
+
+	// Graduated coins quote against their pool, the rest against their curve
+	var quote *trading.Quote
+	if coin.Graduated {
+		var pool models.Pool
+		if err := database.DB.First(&pool, "coin_id = ?", coinID).Error; err != nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Coin has no pool"})
+			return
+		}
+		if solParam != "" {
+			quote, err = trading.NewPoolSolQuote(coin, pool, units.Lamports(solAmount))
+		} else {
+			quote, err = trading.NewPoolQuote(coin, pool, side, units.Tokens(amount))
+		}
+	} else {
+		curve, curveErr := trading.CurveForCoin(&coin)
+		if curveErr != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Invalid coin curve"})
+			return
+		}
+		if solParam != "" {
+			quote, err = trading.NewSolQuote(coin, curve, units.Lamports(solAmount))
+		} else {
+			quote, err = trading.NewQuote(coin, curve, side, units.Tokens(amount))
+		}
+	}
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, quote)
+}
+
 // ========================================
 // Holders / Analytics Handlers
 // ========================================
@@ -264,40 +352,57 @@ func GetHolders(c *gin.Context) {
 		return
 	}
 
-	// Aggregate holdings from trades
-	type HolderResult struct {
-		Wallet    string  `json:"wallet"`
-		NetAmount float64 `json:"netAmount"`
+	var coin models.Coin
+	if err := database.DB.First(&coin, "id = ?", coinID).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Coin not found"})
+		return
 	}
 
-	var results []HolderResult
-	database.DB.Raw(`
-		SELECT wallet, 
-			SUM(CASE WHEN type = 'buy' THEN amount ELSE -amount END) as net_amount
-		FROM trades 
-		WHERE coin_id = ?
-		GROUP BY wallet
-		HAVING SUM(CASE WHEN type = 'buy' THEN amount ELSE -amount END) > 0
-		ORDER BY net_amount DESC
-		LIMIT 50
-	`, coinID).Scan(&results)
-
-	// Calculate percentages
-	var totalHeld float64
-	for _, r := range results {
-		totalHeld += r.NetAmount
+	// Largest holders from the balance ledger
+	var balances []models.Balance
+	database.DB.Where("coin_id = ? AND amount > 0", coinID).
+		Order("amount DESC").
+		Limit(50).
+		Find(&balances)
+
+	// The holding cap counts all of a user's wallets together
+	userHoldings := make(map[string]units.Tokens)
+	if _, capped := trading.HoldingCap(&coin); capped {
+		userIDs := make([]string, 0, len(balances))
+		for _, b := range balances {
+			if b.UserID != "" {
+				userIDs = append(userIDs, b.UserID)
+			}
+		}
+		var rows []struct {
+			UserID string
+			Amount int64
+		}
+		database.DB.Model(&models.Balance{}).
+			Select("user_id, SUM(amount) AS amount").
+			Where("coin_id = ? AND user_id IN ?", coinID, userIDs).
+			Group("user_id").
+			Scan(&rows)
+		for _, row := range rows {
+			userHoldings[row.UserID] = units.Tokens(row.Amount)
+		}
 	}
 
-	holders := make([]database.CachedHolder, len(results))
-	for i, r := range results {
+	holders := make([]database.CachedHolder, len(balances))
+	for i, b := range balances {
 		percent := 0.0
-		if totalHeld > 0 {
-			percent = (r.NetAmount / totalHeld) * 100
+		if coin.TotalSupply > 0 {
+			percent = float64(b.Amount) / float64(coin.TotalSupply) * 100
+		}
+		holding, ok := userHoldings[b.UserID]
+		if !ok {
+			holding = b.Amount
 		}
 		holders[i] = database.CachedHolder{
-			Address: r.Wallet,
-			Amount:  r.NetAmount,
+			Address: b.Wallet,
+			Amount:  b.Amount,
 			Percent: percent,
+			AtCap:   trading.AtHoldingCap(&coin, holding),
 		}
 	}
 
@@ -408,20 +513,25 @@ func GetCoinStats(c *gin.Context) {
 	var tradeCount int64
 	database.DB.Model(&models.Trade{}).Where("coin_id = ?", coinID).Count(&tradeCount)
 
-	// Get unique holders
+	// Get current holders
 	var holderCount int64
-	database.DB.Model(&models.Trade{}).Where("coin_id = ?", coinID).
-		Distinct("wallet").Count(&holderCount)
+	database.DB.Model(&models.Balance{}).Where("coin_id = ? AND amount > 0", coinID).Count(&holderCount)
 
 	// Get 24h volume
-	var volume24h float64
-	database.DB.Model(&models.Trade{}).
-		Where("coin_id = ? AND timestamp > NOW() - INTERVAL '24 hours'", coinID).
-		Select("COALESCE(SUM(amount * price), 0)").Scan(&volume24h)
+	volume24h, _ := market.CoinVolume(database.DB, coinID, time.Now())
 
 	// Calculate bonding curve position
-	curve := blockchain.DefaultCurve()
-	shouldGraduate := curve.ShouldGraduate(coin.MarketCap)
+	shouldGraduate := false
+	if curve, err := trading.CurveForCoin(&coin); err == nil {
+		shouldGraduate = trading.ShouldGraduate(&coin, curve)
+	}
+
+	// Fee totals from the ledger
+	fees, err := trading.SummarizeCoinFees(database.DB, coinID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load fees"})
+		return
+	}
 
 	c.JSON(http.StatusOK, gin.H{
 		"coinId":         coinID,
@@ -432,6 +542,7 @@ func GetCoinStats(c *gin.Context) {
 		"progress":       coin.Progress,
 		"shouldGraduate": shouldGraduate,
 		"graduated":      coin.Graduated,
+		"fees":           fees,
 	})
 }
 
@@ -460,9 +571,16 @@ func RegisterRoutes(api *gin.RouterGroup, authMiddleware gin.HandlerFunc, rateLi
 	// Public routes
 	api.GET("/status", GetBlockchainStatus)
 	api.GET("/trending", GetTrending)
+	api.GET("/market/overview", GetMarketOverview)
 	api.GET("/coins/:id/curve", GetCurveData)
+	api.GET("/coins/:id/candles", GetCandles)
+	api.GET("/coins/:id/quote", GetQuote)
 	api.GET("/coins/:id/holders", GetHolders)
 	api.GET("/coins/:id/stats", GetCoinStats)
+	api.GET("/coins/:id/migration", GetMigration)
+	api.GET("/coins/:id/pool", GetPool)
+	api.GET("/coins/:id/lock", GetLiquidityLock)
+	api.POST("/curves/design", DesignCurve)
 	api.GET("/users/:id/wallets", GetUserWallets)
 	api.GET("/wallet/verify", VerifyWalletOwnership)
 	api.POST("/coins/:id/view", TrackCoinView) // No auth needed for tracking
@@ -479,5 +597,33 @@ func RegisterRoutes(api *gin.RouterGroup, authMiddleware gin.HandlerFunc, rateLi
 		// Wallet management
 		protected.POST("/wallet/link", LinkWallet)
 		protected.DELETE("/wallet/:walletId", UnlinkWallet)
+
+		// SOL account
+		protected.GET("/account", GetAccount)
+		protected.GET("/account/transactions", GetAccountTransactions)
+		protected.POST("/account/deposit", rateLimitMiddleware, Deposit)
+		protected.POST("/account/withdraw", rateLimitMiddleware, Withdraw)
+
+		// Pool liquidity
+		protected.POST("/coins/:id/liquidity", rateLimitMiddleware, AddLiquidity)
+		protected.POST("/coins/:id/liquidity/remove", rateLimitMiddleware, RemoveLiquidity)
+		protected.POST("/coins/:id/lock", rateLimitMiddleware, LockLiquidity)
+
+		// Orders
+		protected.GET("/orders", GetOrders)
+		protected.POST("/orders", rateLimitMiddleware, PlaceOrder)
+		protected.DELETE("/orders/:id", CancelOrder)
+
+		// DCA plans
+		protected.GET("/dca", GetDCAPlans)
+		protected.GET("/dca/:id", GetDCAPlan)
+		protected.POST("/dca", rateLimitMiddleware, CreateDCAPlan)
+		protected.POST("/dca/:id/pause", PauseDCAPlan)
+		protected.POST("/dca/:id/resume", ResumeDCAPlan)
+		protected.POST("/dca/:id/cancel", CancelDCAPlan)
+
+		// Creator fees
+		protected.GET("/creators/fees", GetCreatorFees)
+		protected.POST("/creators/fees/claim", rateLimitMiddleware, ClaimCreatorFees)
 	}
 }
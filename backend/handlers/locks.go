@@ -0,0 +1,110 @@
+package handlers
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"time"
+
+	"memepump/database"
+	"memepump/models"
+	"memepump/trading"
+
+	"github.com/gin-gonic/gin"
+)
+
+type LockLiquidityRequest struct {
+	Until    time.Time `json:"until"`    // Absolute end of the lock
+	Duration int64     `json:"duration"` // Or seconds from now
+}
+
+// ========================================
+// Liquidity Lock Handlers
+// ========================================
+
+// GetLiquidityLock returns a coin's lock status and history
+func GetLiquidityLock(c *gin.Context) {
+	var coin models.Coin
+	if err := database.DB.First(&coin, "id = ?", c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Coin not found"})
+		return
+	}
+
+	var history []models.LockEvent
+	database.DB.Where("coin_id = ?", coin.ID).Order("created_at DESC").Find(&history)
+
+	now := time.Now()
+	remaining := int64(0)
+	if trading.LiquidityLockActive(&coin, now) {
+		remaining = int64(coin.LockUntil.Sub(now).Seconds())
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"coinId":       coin.ID,
+		"locked":       trading.LiquidityLockActive(&coin, now),
+		"lockUntil":    coin.LockUntil,
+		"lockedAmount": coin.LockedAmount,
+		"remaining":    remaining,
+		"history":      history,
+	})
+}
+
+// LockLiquidity lets a coin's creator lock its liquidity or extend the lock
+func LockLiquidity(c *gin.Context) {
+	userID := c.GetString("userID")
+
+	var req LockLiquidityRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	now := time.Now()
+	until := req.Until
+	if until.IsZero() {
+		if req.Duration <= 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "until or a positive duration is required"})
+			return
+		}
+		until = now.Add(time.Duration(req.Duration) * time.Second)
+	}
+
+	var coin models.Coin
+	if err := database.DB.Select("id", "creator_user_id").First(&coin, "id = ?", c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Coin not found"})
+		return
+	}
+	if coin.CreatorUserID == "" || coin.CreatorUserID != userID {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Only the coin's creator can lock its liquidity"})
+		return
+	}
+
+	tx := database.DB.Begin()
+	locked, event, err := trading.LockLiquidity(tx, coin.ID, userID, until, now)
+	if err != nil {
+		tx.Rollback()
+		switch {
+		case errors.Is(err, trading.ErrCoinNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"error": "Coin not found"})
+		case errors.Is(err, trading.ErrLockShortening):
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		case errors.Is(err, trading.ErrStorage):
+			log.Println("Liquidity lock failed:", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to lock liquidity"})
+		default:
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		}
+		return
+	}
+	if err := tx.Commit().Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to lock liquidity"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"coinId":       locked.ID,
+		"locked":       true,
+		"lockUntil":    locked.LockUntil,
+		"lockedAmount": locked.LockedAmount,
+		"event":        event,
+	})
+}
@@ -0,0 +1,35 @@
+package handlers
+
+import (
+	"log"
+	"net/http"
+	"time"
+
+	"memepump/database"
+	"memepump/market"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ========================================
+// Market Handlers
+// ========================================
+
+// GetMarketOverview returns market-wide volume, activity, launches and the top
+// movers and volume leaders, cached for a few seconds
+func GetMarketOverview(c *gin.Context) {
+	var overview market.Overview
+	if database.GetCachedMarketOverview(&overview) {
+		c.JSON(http.StatusOK, overview)
+		return
+	}
+
+	built, err := market.Build(database.DB, time.Now())
+	if err != nil {
+		log.Println("Market overview failed:", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to build market overview"})
+		return
+	}
+	database.CacheMarketOverview(built)
+	c.JSON(http.StatusOK, built)
+}
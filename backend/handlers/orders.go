@@ -0,0 +1,129 @@
+package handlers
+
+import (
+	"errors"
+	"log"
+	"net/http"
+
+	"memepump/database"
+	"memepump/models"
+	"memepump/trading"
+	"memepump/units"
+
+	"github.com/gin-gonic/gin"
+)
+
+type PlaceOrderRequest struct {
+	CoinID         string         `json:"coinId" binding:"required"`
+	Side           string         `json:"side"` // "buy" or "sell"; rules always sell
+	Type           string         `json:"type"` // "limit" (default), "stop_loss", "take_profit" or "trailing_stop"
+	Wallet         string         `json:"wallet" binding:"required"`
+	TriggerPrice   units.Price    `json:"triggerPrice"`
+	TriggerPercent float64        `json:"triggerPercent"` // Rules: percent from the average entry, or the trail
+	SolAmount      units.Lamports `json:"solAmount"`      // Buy budget, fees included
+	Amount         units.Tokens   `json:"amount"`         // Tokens to sell, omit to sell the whole balance
+	SlippageBps    int64          `json:"slippageBps"`    // Rules: defaults to RULE_SLIPPAGE_BPS
+}
+
+// ========================================
+// Order Handlers
+// ========================================
+
+// PlaceOrder opens a limit order, reserving its funds, or a stop-loss, take-profit
+// or trailing stop on the caller's position
+func PlaceOrder(c *gin.Context) {
+	userID := c.GetString("userID")
+
+	var req PlaceOrderRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var user models.User
+	if err := database.DB.First(&user, "id = ?", userID).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		return
+	}
+
+	order := models.Order{
+		UserID:       userID,
+		Username:     user.Username,
+		CoinID:       req.CoinID,
+		Type:         req.Type,
+		Side:         req.Side,
+		Wallet:       req.Wallet,
+		TriggerPrice: req.TriggerPrice,
+		SolAmount:    req.SolAmount,
+		Amount:       req.Amount,
+
+		TriggerPercent: req.TriggerPercent,
+		SlippageBps:    req.SlippageBps,
+	}
+
+	tx := database.DB.Begin()
+	if err := trading.PlaceOrder(tx, &order); err != nil {
+		tx.Rollback()
+		writeOrderError(c, err)
+		return
+	}
+	if err := tx.Commit().Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to place order"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, order)
+}
+
+// GetOrders returns the caller's orders, newest first, optionally by coin and status
+func GetOrders(c *gin.Context) {
+	query := database.DB.Where("user_id = ?", c.GetString("userID")).Order("created_at DESC").Limit(100)
+	if coinID := c.Query("coinId"); coinID != "" {
+		query = query.Where("coin_id = ?", coinID)
+	}
+	if status := c.Query("status"); status != "" {
+		query = query.Where("status = ?", status)
+	}
+
+	var orders []models.Order
+	if err := query.Find(&orders).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load orders"})
+		return
+	}
+	c.JSON(http.StatusOK, orders)
+}
+
+// CancelOrder closes one of the caller's open orders and releases its funds
+func CancelOrder(c *gin.Context) {
+	tx := database.DB.Begin()
+	order, err := trading.CancelOrder(tx, c.Param("id"), c.GetString("userID"))
+	if err != nil {
+		tx.Rollback()
+		writeOrderError(c, err)
+		return
+	}
+	if err := tx.Commit().Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to cancel order"})
+		return
+	}
+
+	c.JSON(http.StatusOK, order)
+}
+
+func writeOrderError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, trading.ErrCoinNotFound), errors.Is(err, trading.ErrOrderNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case errors.Is(err, trading.ErrWalletNotOwned), errors.Is(err, trading.ErrWalletNotLinked):
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+	case errors.Is(err, trading.ErrOrderNotOpen):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	case errors.Is(err, trading.ErrInsufficientFunds):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error(), "code": "INSUFFICIENT_FUNDS"})
+	case errors.Is(err, trading.ErrStorage):
+		log.Println("Order update failed:", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update order"})
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	}
+}
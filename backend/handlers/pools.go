@@ -0,0 +1,101 @@
+package handlers
+
+import (
+	"errors"
+	"log"
+	"net/http"
+
+	"memepump/database"
+	"memepump/models"
+	"memepump/trading"
+	"memepump/units"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+type AddLiquidityRequest struct {
+	Wallet    string         `json:"wallet" binding:"required"` // Supplies the tokens
+	SolAmount units.Lamports `json:"solAmount"`
+}
+
+type RemoveLiquidityRequest struct {
+	Wallet string `json:"wallet" binding:"required"` // Receives the tokens
+	Shares int64  `json:"shares,string"`
+}
+
+// ========================================
+// Pool Handlers
+// ========================================
+
+// GetPool returns a graduated coin's pool and its LP positions
+func GetPool(c *gin.Context) {
+	coinID := c.Param("id")
+
+	var pool models.Pool
+	if err := database.DB.First(&pool, "coin_id = ?", coinID).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Coin has no pool"})
+		return
+	}
+
+	var positions []models.LPPosition
+	database.DB.Where("coin_id = ? AND shares > 0", coinID).Order("shares DESC").Find(&positions)
+
+	c.JSON(http.StatusOK, gin.H{
+		"pool":      pool,
+		"price":     trading.PoolPrice(&pool),
+		"positions": positions,
+	})
+}
+
+// AddLiquidity deposits SOL from the caller's account and matching tokens from their wallet
+func AddLiquidity(c *gin.Context) {
+	var req AddLiquidityRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	tx := database.DB.Begin()
+	result, err := trading.AddLiquidity(tx, c.Param("id"), c.GetString("userID"), req.Wallet, req.SolAmount)
+	commitLiquidity(c, tx, result, err)
+}
+
+// RemoveLiquidity burns the caller's LP shares for their part of the reserves
+func RemoveLiquidity(c *gin.Context) {
+	var req RemoveLiquidityRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	tx := database.DB.Begin()
+	result, err := trading.RemoveLiquidity(tx, c.Param("id"), c.GetString("userID"), req.Wallet, req.Shares)
+	commitLiquidity(c, tx, result, err)
+}
+
+func commitLiquidity(c *gin.Context, tx *gorm.DB, result *trading.LiquidityResult, err error) {
+	if err != nil {
+		tx.Rollback()
+		switch {
+		case errors.Is(err, trading.ErrCoinNotFound), errors.Is(err, trading.ErrPoolNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		case errors.Is(err, trading.ErrWalletNotOwned), errors.Is(err, trading.ErrWalletNotLinked), errors.Is(err, trading.ErrLiquidityLocked):
+			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+		case errors.Is(err, trading.ErrInsufficientFunds):
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error(), "code": "INSUFFICIENT_FUNDS"})
+		case errors.Is(err, trading.ErrStorage):
+			log.Println("Liquidity update failed:", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update liquidity"})
+		default:
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		}
+		return
+	}
+	if err := tx.Commit().Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update liquidity"})
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
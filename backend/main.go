@@ -1,26 +1,34 @@
 package main
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"log"
 	"math"
 	"net/http"
 	"os"
+	"strconv"
 	"time"
 
 	"memepump/auth"
+	"memepump/blockchain"
 	"memepump/database"
+	"memepump/dca"
+	"memepump/graduation"
 	"memepump/handlers"
 	"memepump/middleware"
 	"memepump/models"
 	"memepump/realtime"
+	"memepump/sequencer"
+	"memepump/trading"
+	"memepump/units"
 
 	"github.com/gin-contrib/cors"
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
 	"github.com/gorilla/websocket"
 	"gorm.io/gorm"
-	"gorm.io/gorm/clause"
 )
 
 // Config
@@ -28,8 +36,14 @@ var (
 	PORT       = os.Getenv("PORT")
 	DB_DSN     string
 	REDIS_ADDR = os.Getenv("REDIS_ADDR")
+
+	// TRADE_SEQUENCER=off commits every trade in its own transaction
+	TRADE_SEQUENCER = os.Getenv("TRADE_SEQUENCER")
 )
 
+// tradeSequencer batches each coin's trades through a single writer, nil when disabled
+var tradeSequencer *sequencer.Sequencer
+
 func init() {
 	if PORT == "" {
 		PORT = "8080"
@@ -51,6 +65,62 @@ func init() {
 	if REDIS_ADDR == "" {
 		REDIS_ADDR = "localhost:6379"
 	}
+
+	fees := trading.Fees
+	if v := os.Getenv("PLATFORM_FEE_BPS"); v != "" {
+		fees.PlatformBps = mustParseBps("PLATFORM_FEE_BPS", v)
+	}
+	if v := os.Getenv("CREATOR_FEE_BPS"); v != "" {
+		fees.CreatorBps = mustParseBps("CREATOR_FEE_BPS", v)
+	}
+	if err := fees.Validate(); err != nil {
+		log.Fatal("Invalid fee configuration: ", err)
+	}
+	trading.Fees = fees
+
+	if v := os.Getenv("SWAP_FEE_BPS"); v != "" {
+		trading.SwapFeeBps = mustParseBps("SWAP_FEE_BPS", v)
+		if trading.SwapFeeBps < 0 || trading.SwapFeeBps > trading.MaxFeeBps {
+			log.Fatalf("Invalid SWAP_FEE_BPS %d", trading.SwapFeeBps)
+		}
+	}
+
+	if v := os.Getenv("IDEMPOTENCY_WINDOW"); v != "" {
+		window, err := time.ParseDuration(v)
+		if err != nil || window <= 0 {
+			log.Fatalf("Invalid IDEMPOTENCY_WINDOW %q", v)
+		}
+		middleware.IdempotencyWindow = window
+	}
+	if v := os.Getenv("IDEMPOTENCY_LEASE"); v != "" {
+		lease, err := time.ParseDuration(v)
+		if err != nil || lease <= 0 {
+			log.Fatalf("Invalid IDEMPOTENCY_LEASE %q", v)
+		}
+		middleware.IdempotencyLease = lease
+	}
+
+	if v := os.Getenv("MAX_HOLDING_BPS"); v != "" {
+		trading.DefaultMaxHoldingBps = mustParseBps("MAX_HOLDING_BPS", v)
+		if err := trading.ValidateHoldingCap(trading.DefaultMaxHoldingBps); err != nil {
+			log.Fatal("Invalid MAX_HOLDING_BPS: ", err)
+		}
+	}
+
+	if v := os.Getenv("RULE_SLIPPAGE_BPS"); v != "" {
+		trading.RuleSlippageBps = mustParseBps("RULE_SLIPPAGE_BPS", v)
+		if trading.RuleSlippageBps <= 0 || trading.RuleSlippageBps > trading.BpsDenominator {
+			log.Fatalf("Invalid RULE_SLIPPAGE_BPS %d", trading.RuleSlippageBps)
+		}
+	}
+}
+
+func mustParseBps(name, value string) int64 {
+	bps, err := strconv.ParseInt(value, 10, 64)
+	if err != nil {
+		log.Fatalf("Invalid %s %q: %v", name, value, err)
+	}
+	return bps
 }
 
 // WebSocket Upgrader
@@ -60,31 +130,35 @@ var upgrader = websocket.Upgrader{
 	},
 }
 
-// Bonding Curve Logic
-func calculatePrice(supply float64) float64 {
-	return math.Pow(supply, 2) / 1000000
-}
-
-func calculateMarketCap(coin *models.Coin) float64 {
-	return coin.Price * coin.TotalSupply
-}
-
-func calculateProgress(marketCap float64) float64 {
-	target := 100000.0
-	progress := (marketCap / target) * 100
-	if progress > 100 {
-		progress = 100
-	}
-	return progress
-}
-
 func main() {
 	// Connect to Database
 	database.Connect(DB_DSN)
 
+	// memepump verify [-repair] [-coin id] checks coin state against the event log
+	if len(os.Args) > 1 && os.Args[1] == "verify" {
+		os.Exit(runVerify(os.Args[2:]))
+	}
+	// memepump candles [-coin id] rebuilds candles from the trade history
+	if len(os.Args) > 1 && os.Args[1] == "candles" {
+		os.Exit(runRebuildCandles(os.Args[2:]))
+	}
+
+	if err := trading.BackfillCandles(database.DB); err != nil {
+		log.Fatal("Failed to backfill candles: ", err)
+	}
+
 	// Connect to Redis
 	database.ConnectRedis(REDIS_ADDR, "")
 
+	// Sequence trades per coin, sharing coins between instances through Redis
+	if TRADE_SEQUENCER != "off" {
+		tradeSequencer = sequencer.New(&sequencer.DBCommitter{DB: database.DB}, &sequencer.RedisOwnership{
+			Client:     database.RDB,
+			InstanceID: uuid.New().String(),
+			Lease:      sequencer.DefaultLease,
+		})
+	}
+
 	// Initialize Server
 	r := gin.Default()
 
@@ -92,8 +166,8 @@ func main() {
 	r.Use(cors.New(cors.Config{
 		AllowOrigins:     []string{"*"},
 		AllowMethods:     []string{"GET", "POST", "PUT", "PATCH", "DELETE", "OPTIONS"},
-		AllowHeaders:     []string{"Origin", "Content-Type", "Accept", "Authorization"},
-		ExposeHeaders:    []string{"Content-Length"},
+		AllowHeaders:     []string{"Origin", "Content-Type", "Accept", "Authorization", middleware.IdempotencyHeader},
+		ExposeHeaders:    []string{"Content-Length", middleware.ReplayedHeader},
 		AllowCredentials: true,
 		MaxAge:           12 * time.Hour,
 	}))
@@ -121,8 +195,8 @@ func main() {
 		protected := api.Group("/")
 		protected.Use(middleware.AuthMiddleware())
 		{
-			protected.POST("/coins", middleware.RateLimitMiddleware(), createCoin)
-			protected.POST("/trade", middleware.RateLimitMiddleware(), executeTrade)
+			protected.POST("/coins", middleware.RateLimitMiddleware(), middleware.IdempotencyMiddleware(), createCoin)
+			protected.POST("/trade", middleware.RateLimitMiddleware(), middleware.IdempotencyMiddleware(), executeTrade)
 			protected.POST("/comments", middleware.RateLimitMiddleware(), createComment)
 			protected.POST("/comments/:coinId/:commentId/like", likeComment)
 			protected.PUT("/users/:id", updateUser)
@@ -135,6 +209,15 @@ func main() {
 	// Initialize Mock Data if needed
 	go initMockData()
 
+	// Migrate graduated coins to their DEX
+	go graduation.Run(context.Background())
+
+	// Run scheduled DCA buys like any other trade
+	go dca.Run(context.Background(), func(result *trading.Result) {
+		broadcastTrade(result)
+		matchOrders(result.Trade.CoinID)
+	})
+
 	log.Printf("Server starting on port %s", PORT)
 	r.Run(":" + PORT)
 }
@@ -305,24 +388,65 @@ func createCoin(c *gin.Context) {
 		return
 	}
 
+	curve, err := trading.NewCurve(req.Curve)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	// The creator is the authenticated user, whatever name the request carries
+	userID := c.GetString("userID")
+	var creator models.User
+	if err := database.DB.First(&creator, "id = ?", userID).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		return
+	}
+
+	// The initial buy lands in the creator's wallet
+	creatorWallet := req.CreatorWallet
+	if creatorWallet == "" {
+		var primary models.WalletLink
+		if err := database.DB.Where("user_id = ? AND is_primary = ?", userID, true).First(&primary).Error; err == nil {
+			creatorWallet = primary.Address
+		}
+	}
+	if req.InitialBuyAmount > 0 && creatorWallet == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "creatorWallet is required for an initial buy"})
+		return
+	}
+
 	coin := models.Coin{
-		ID:          uuid.New().String(),
-		Name:        req.Name,
-		Symbol:      req.Symbol,
-		Description: req.Description,
-		Image:       req.Image,
-		Creator:     req.Creator,
-		Twitter:     req.Twitter,
-		Telegram:    req.Telegram,
-		Website:     req.Website,
-		TotalSupply: 1000000000,
-		Price:       0.0001,
-		CreatedAt:   time.Now(),
-		Holders:     1,
-	}
-
-	coin.MarketCap = calculateMarketCap(&coin)
-	coin.Progress = calculateProgress(coin.MarketCap)
+		ID:            uuid.New().String(),
+		Name:          req.Name,
+		Symbol:        req.Symbol,
+		Description:   req.Description,
+		Image:         req.Image,
+		Creator:       creator.Username,
+		CreatorUserID: creator.ID,
+		Twitter:       req.Twitter,
+		Telegram:      req.Telegram,
+		Website:       req.Website,
+		CreatorWallet: creatorWallet,
+		CreatedAt:     time.Now(),
+	}
+	trading.InitCoin(&coin, curve)
+
+	coin.MaxHoldingBps = trading.DefaultMaxHoldingBps
+	if req.MaxHoldingBps != 0 {
+		if err := trading.ValidateHoldingCap(req.MaxHoldingBps); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		coin.MaxHoldingBps = req.MaxHoldingBps
+	}
+
+	if req.LaunchRules != nil {
+		if err := trading.ValidateLaunchRules(req.LaunchRules); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		coin.LaunchRules = *req.LaunchRules
+	}
 
 	tx := database.DB.Begin()
 
@@ -331,49 +455,40 @@ func createCoin(c *gin.Context) {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create coin"})
 		return
 	}
+	if err := trading.SaveLaunchAllowlist(tx, coin.ID, coin.LaunchRules.Allowlist); err != nil {
+		tx.Rollback()
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create coin"})
+		return
+	}
 
 	// Handle Initial Buy
+	var initialBuy *trading.Result
 	if req.InitialBuyAmount > 0 {
-		var trade models.Trade // Fix: declare type explicitly
-		trade = models.Trade{
-			ID:        uuid.New().String(),
-			CoinID:    coin.ID,
-			Type:      "buy",
-			Amount:    req.InitialBuyAmount,
-			Price:     coin.Price,
-			Wallet:    "CREATOR_WALLET",
-			Username:  req.Creator,
-			Timestamp: time.Now(),
-		}
-
-		// Update Coin Supply
-		coin.TotalSupply += (req.InitialBuyAmount * 1000000)
-		coin.Price = calculatePrice(coin.TotalSupply)
-		coin.MarketCap = calculateMarketCap(&coin)
-		coin.Progress = calculateProgress(coin.MarketCap)
-
-		if err := tx.Save(&coin).Error; err != nil {
-			tx.Rollback()
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update coin"})
-			return
-		}
-
-		if err := tx.Create(&trade).Error; err != nil {
+		initialBuy, err = trading.Execute(tx, &models.TradeRequest{
+			CoinID:   coin.ID,
+			Type:     trading.SideBuy,
+			Amount:   req.InitialBuyAmount,
+			Wallet:   creatorWallet,
+			Username: creator.Username,
+			UserID:   userID,
+		})
+		if err != nil {
 			tx.Rollback()
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create trade"})
+			writeTradeError(c, err)
 			return
 		}
-
-		// Broadcast trade
-		go realtime.BroadcastSafe("trade", map[string]interface{}{
-			"trade": trade,
-			"coin":  coin,
-		})
+		coin = initialBuy.Coin
 	}
 
-	tx.Commit()
+	if err := tx.Commit().Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create coin"})
+		return
+	}
 
 	realtime.BroadcastSafe("coinCreated", coin)
+	if initialBuy != nil {
+		go broadcastTrade(initialBuy)
+	}
 	c.JSON(http.StatusCreated, coin)
 }
 
@@ -402,74 +517,117 @@ func executeTrade(c *gin.Context) {
 		return
 	}
 
-	if req.Amount <= 0 {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Amount must be positive"})
-		return
-	}
+	req.UserID = c.GetString("userID")
 
-	tx := database.DB.Begin()
-
-	var coin models.Coin
-	// Lock row for update
-	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&coin, "id = ?", req.CoinID).Error; err != nil {
-		tx.Rollback()
-		c.JSON(http.StatusNotFound, gin.H{"error": "Coin not found"})
+	result, err := commitTrade(&req)
+	if err != nil {
+		writeTradeError(c, err)
 		return
 	}
 
-	var newSupply float64
-	if req.Type == "buy" {
-		newSupply = coin.TotalSupply + (req.Amount * 1000000)
-	} else {
-		newSupply = coin.TotalSupply - (req.Amount * 1000000)
-		if newSupply < 0 {
-			tx.Rollback()
-			c.JSON(http.StatusBadRequest, gin.H{"error": "Insufficient supply"})
-			return
-		}
-
-		// Optional: Check user balance here if we were enforcing it strictly
-	}
+	go func() {
+		broadcastTrade(result)
+		matchOrders(req.CoinID)
+	}()
 
-	coin.TotalSupply = newSupply
-	coin.Price = calculatePrice(coin.TotalSupply)
-	coin.MarketCap = calculateMarketCap(&coin)
-	coin.Progress = calculateProgress(coin.MarketCap)
+	c.JSON(http.StatusOK, gin.H{
+		"trade": result.Trade,
+		"coin":  result.Coin,
+	})
+}
 
-	trade := models.Trade{
-		ID:        uuid.New().String(),
-		CoinID:    req.CoinID,
-		Type:      req.Type,
-		Amount:    req.Amount,
-		Price:     coin.Price,
-		Wallet:    req.Wallet,
-		Username:  req.Username,
-		Timestamp: time.Now(),
+// commitTrade applies a trade through the sequencer, or in its own transaction
+// when the sequencer is disabled
+func commitTrade(req *models.TradeRequest) (*trading.Result, error) {
+	if tradeSequencer != nil {
+		return tradeSequencer.Submit(req)
 	}
 
-	if err := tx.Save(&coin).Error; err != nil {
-		tx.Rollback()
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update coin"})
-		return
+	tx := database.DB.Begin()
+	defer tx.Rollback()
+	result, err := trading.Execute(tx, req)
+	if err != nil {
+		return nil, err
 	}
-
-	if err := tx.Create(&trade).Error; err != nil {
-		tx.Rollback()
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create trade"})
-		return
+	if err := tx.Commit().Error; err != nil {
+		return nil, fmt.Errorf("%w: %v", trading.ErrStorage, err)
 	}
+	return result, nil
+}
 
-	tx.Commit()
-
-	go realtime.BroadcastSafe("trade", map[string]interface{}{
-		"trade": trade,
-		"coin":  coin,
+// broadcastTrade announces a committed trade, and the graduation it caused if any
+func broadcastTrade(result *trading.Result) {
+	realtime.BroadcastSafe("trade", map[string]interface{}{
+		"trade": result.Trade,
+		"coin":  result.Coin,
 	})
+	if result.Migration != nil {
+		realtime.BroadcastSafe("coinGraduated", map[string]interface{}{
+			"coin":      result.Coin,
+			"migration": result.Migration,
+		})
+	}
+}
 
-	c.JSON(http.StatusOK, gin.H{
-		"trade": trade,
-		"coin":  coin,
-	})
+// matchOrders fills the coin's triggered orders after a committed trade and
+// broadcasts each fill
+func matchOrders(coinID string) {
+	fills, err := trading.MatchOrders(database.DB, coinID)
+	if err != nil {
+		log.Println("Order matching failed:", err)
+	}
+	for i := range fills {
+		fill := &fills[i]
+		if fill.Result == nil {
+			realtime.BroadcastSafe("orderFailed", fill)
+			continue
+		}
+		broadcastTrade(fill.Result)
+		realtime.BroadcastSafe("orderFilled", fill)
+	}
+}
+
+// writeTradeError maps trading errors to HTTP responses
+func writeTradeError(c *gin.Context, err error) {
+	var slippage *trading.SlippageError
+	var launch *trading.LaunchRuleError
+	switch {
+	case errors.As(err, &slippage):
+		c.JSON(http.StatusConflict, gin.H{
+			"error": slippage.Error(),
+			"code":  "SLIPPAGE_EXCEEDED",
+			"bound": slippage.Bound,
+			"limit": slippage.Limit,
+			"fill":  slippage.Fill,
+		})
+	case errors.As(err, &launch):
+		c.JSON(http.StatusForbidden, gin.H{
+			"error":      launch.Error(),
+			"code":       "LAUNCH_RULE",
+			"rule":       launch.Rule,
+			"retryAfter": int64(math.Ceil(launch.RetryAfter.Seconds())),
+		})
+	case errors.Is(err, trading.ErrQuoteExpired):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error(), "code": "QUOTE_EXPIRED"})
+	case errors.Is(err, trading.ErrInsufficientFunds):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error(), "code": "INSUFFICIENT_FUNDS"})
+	case errors.Is(err, trading.ErrWalletNotOwned), errors.Is(err, trading.ErrWalletNotLinked):
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+	case errors.Is(err, trading.ErrHoldingCap):
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error(), "code": "HOLDING_CAP"})
+	case errors.Is(err, trading.ErrCoinNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Coin not found"})
+	case errors.Is(err, trading.ErrPoolNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Coin has no pool"})
+	case errors.Is(err, sequencer.ErrOverloaded):
+		c.Header("Retry-After", "1")
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": err.Error()})
+	case errors.Is(err, trading.ErrInvalidCurve), errors.Is(err, trading.ErrStorage):
+		log.Println("Trade failed:", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to execute trade"})
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	}
 }
 
 func getTrades(c *gin.Context) {
@@ -552,28 +710,20 @@ func likeComment(c *gin.Context) {
 func getPortfolio(c *gin.Context) {
 	userID := c.Param("id")
 
-	// Get user to verify existence (and username)
+	// Get user to verify existence
 	var user models.User
 	if err := database.DB.First(&user, "id = ?", userID).Error; err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
 		return
 	}
 
-	// Get all trades for user
-	var trades []models.Trade
-	database.DB.Where("username = ?", user.Username).Order("timestamp asc").Find(&trades)
-	// Note: Schema has UserID? models.Trade had `Username` string. Model def said: Username string.
-	// If we updated Trade model to have UserID, we should use that. model.go shows "Username string".
-	// Sticking to Username as per original design for now.
-
-	portfolio := make(map[string]*models.PortfolioItem)
+	// Holdings across all of the user's wallets
+	var balances []models.Balance
+	database.DB.Where("user_id = ? AND amount > 0", user.ID).Find(&balances)
 
-	// Get all coins involved to minimize N+1
-	// Actually let's just fetch them as needed or prefetch.
-	// Optimize: Collect Coin IDs first.
-	coinIDs := make([]string, 0)
-	for _, t := range trades {
-		coinIDs = append(coinIDs, t.CoinID)
+	coinIDs := make([]string, 0, len(balances))
+	for _, b := range balances {
+		coinIDs = append(coinIDs, b.CoinID)
 	}
 
 	var coins []models.Coin
@@ -583,39 +733,28 @@ func getPortfolio(c *gin.Context) {
 		coinMap[coins[i].ID] = &coins[i]
 	}
 
-	for _, trade := range trades {
-		if _, exists := portfolio[trade.CoinID]; !exists {
-			if coin, ok := coinMap[trade.CoinID]; ok {
-				portfolio[trade.CoinID] = &models.PortfolioItem{
-					Coin:     coin,
-					Amount:   0,
-					Value:    0,
-					AvgPrice: 0,
-				}
-			} else {
-				continue
-			}
+	// One item per coin, summing wallets
+	portfolio := make(map[string]*models.PortfolioItem)
+	costBasis := make(map[string]units.Lamports)
+	for _, b := range balances {
+		coin, ok := coinMap[b.CoinID]
+		if !ok {
+			continue
 		}
-
-		item := portfolio[trade.CoinID]
-
-		if trade.Type == "buy" {
-			totalCost := (item.Amount * item.AvgPrice) + (trade.Amount * trade.Price)
-			item.Amount += trade.Amount
-			if item.Amount > 0 {
-				item.AvgPrice = totalCost / item.Amount
-			}
-		} else {
-			item.Amount -= trade.Amount
+		item, exists := portfolio[b.CoinID]
+		if !exists {
+			item = &models.PortfolioItem{Coin: coin}
+			portfolio[b.CoinID] = item
 		}
+		item.Amount += b.Amount
+		costBasis[b.CoinID] += b.CostBasis
 	}
 
-	var result []models.PortfolioItem
-	for _, item := range portfolio {
-		if item.Amount > 0.000001 {
-			item.Value = item.Amount * item.Coin.Price
-			result = append(result, *item)
-		}
+	result := make([]models.PortfolioItem, 0, len(portfolio))
+	for coinID, item := range portfolio {
+		item.AvgPrice = units.PriceOf(costBasis[coinID], item.Amount)
+		item.Value = units.Value(item.Amount, item.Coin.Price)
+		result = append(result, *item)
 	}
 
 	c.JSON(http.StatusOK, result)
@@ -642,40 +781,54 @@ func initMockData() {
 		CreatedAt: time.Now(),
 	}
 	database.DB.Create(&user1)
+	user2 := models.User{
+		ID:        uuid.New().String(),
+		Username:  "MoonBoy",
+		Avatar:    "🌙",
+		Bio:       "Wen moon",
+		Pin:       hashedPin,
+		CreatedAt: time.Now(),
+	}
+	database.DB.Create(&user2)
 
 	// Mock coins
-	mockCoins := []models.CreateCoinRequest{
+	mockCoins := []struct {
+		models.CreateCoinRequest
+		Creator models.User
+	}{
 		{
-			Name:        "Pepe Rocket",
-			Symbol:      "PEPERK",
-			Description: "To the moon! 🚀",
-			Image:       "🐸",
-			Creator:     "CryptoKing",
+			CreateCoinRequest: models.CreateCoinRequest{
+				Name:        "Pepe Rocket",
+				Symbol:      "PEPERK",
+				Description: "To the moon! 🚀",
+				Image:       "🐸",
+			},
+			Creator: user1,
 		},
 		{
-			Name:        "Doge Galaxy",
-			Symbol:      "DOGEGX",
-			Description: "Much wow, very moon",
-			Image:       "🐕",
-			Creator:     "MoonBoy",
+			CreateCoinRequest: models.CreateCoinRequest{
+				Name:        "Doge Galaxy",
+				Symbol:      "DOGEGX",
+				Description: "Much wow, very moon",
+				Image:       "🐕",
+			},
+			Creator: user2,
 		},
 	}
 
+	curve := blockchain.DefaultCurve()
 	for _, req := range mockCoins {
 		coin := models.Coin{
-			ID:          uuid.New().String(),
-			Name:        req.Name,
-			Symbol:      req.Symbol,
-			Description: req.Description,
-			Image:       req.Image,
-			Creator:     req.Creator,
-			TotalSupply: 1000000000,
-			Price:       0.0001,
-			CreatedAt:   time.Now(),
-			Holders:     1,
+			ID:            uuid.New().String(),
+			Name:          req.Name,
+			Symbol:        req.Symbol,
+			Description:   req.Description,
+			Image:         req.Image,
+			Creator:       req.Creator.Username,
+			CreatorUserID: req.Creator.ID,
+			CreatedAt:     time.Now(),
 		}
-		coin.MarketCap = calculateMarketCap(&coin)
-		coin.Progress = calculateProgress(coin.MarketCap)
+		trading.InitCoin(&coin, curve)
 		database.DB.Create(&coin)
 	}
 }
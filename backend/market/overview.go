@@ -0,0 +1,254 @@
+// Package market summarises trading across all coins from the candles every
+// trade maintains, so the overview never scans the trades table.
+package market
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"memepump/models"
+	"memepump/trading"
+	"memepump/units"
+
+	"gorm.io/gorm"
+)
+
+// LeaderboardSize is how many coins each leaderboard lists
+const LeaderboardSize = 10
+
+// Window is a rolling period movers are measured over, read from candles of Interval
+type Window struct {
+	Name     string
+	Duration time.Duration
+	Interval string
+}
+
+// Windows are the mover periods, each resolved to its candle interval
+var Windows = []Window{
+	{"5m", 5 * time.Minute, "1m"},
+	{"1h", time.Hour, "1m"},
+	{"24h", 24 * time.Hour, "5m"},
+}
+
+// volumeWindow is the period of the volume totals and leaderboard
+var volumeWindow = Windows[len(Windows)-1]
+
+// CoinSummary is what leaderboards show of a coin
+type CoinSummary struct {
+	ID        string         `json:"id"`
+	Name      string         `json:"name"`
+	Symbol    string         `json:"symbol"`
+	Image     string         `json:"image"`
+	Price     units.Price    `json:"price"`
+	MarketCap units.Lamports `json:"marketCap"`
+	Graduated bool           `json:"graduated"`
+}
+
+// Mover is a coin's price change over a window
+type Mover struct {
+	Coin        CoinSummary `json:"coin"`
+	PriceBefore units.Price `json:"priceBefore"` // Spot price at the start of the window
+	Change      float64     `json:"change"`      // Percent
+}
+
+// VolumeLeader is a coin's trading over the volume window
+type VolumeLeader struct {
+	Coin   CoinSummary    `json:"coin"`
+	Volume units.Lamports `json:"volume"`
+	Trades int64          `json:"trades"`
+}
+
+// HourCount counts coins created in the hour starting at Hour
+type HourCount struct {
+	Hour  time.Time `json:"hour"`
+	Count int64     `json:"count"`
+}
+
+// Overview is the market-wide summary
+type Overview struct {
+	Volume24h       units.Lamports     `json:"volume24h"` // SOL traded, before fees
+	Trades24h       int64              `json:"trades24h"`
+	ActiveCoins     int64              `json:"activeCoins"`     // Coins traded in the last 24h
+	NewCoinsPerHour []HourCount        `json:"newCoinsPerHour"` // Last 24 hours, oldest first
+	Gainers         map[string][]Mover `json:"gainers"`         // By window name
+	Losers          map[string][]Mover `json:"losers"`
+	TopVolume       []VolumeLeader     `json:"topVolume"`
+	UpdatedAt       time.Time          `json:"updatedAt"`
+}
+
+// windowStart returns the first candle a rolling window reads, to the candle interval
+func windowStart(now time.Time, window Window) time.Time {
+	interval, _ := trading.LookupCandleInterval(window.Interval)
+	return trading.CandleStart(now.Add(-window.Duration), interval.Duration)
+}
+
+// Build computes the overview as of now
+func Build(db *gorm.DB, now time.Time) (*Overview, error) {
+	overview := &Overview{
+		Gainers:   make(map[string][]Mover, len(Windows)),
+		Losers:    make(map[string][]Mover, len(Windows)),
+		TopVolume: []VolumeLeader{},
+		UpdatedAt: now,
+	}
+
+	since := windowStart(now, volumeWindow)
+	row := db.Model(&models.Candle{}).
+		Where("period = ? AND start >= ?", volumeWindow.Interval, since).
+		Select("COALESCE(SUM(volume), 0), COALESCE(SUM(trades), 0), COUNT(DISTINCT coin_id)").Row()
+	if err := row.Scan(&overview.Volume24h, &overview.Trades24h, &overview.ActiveCoins); err != nil {
+		return nil, fmt.Errorf("volume totals: %w", err)
+	}
+
+	var leaders []struct {
+		CoinID string
+		Volume units.Lamports
+		Trades int64
+	}
+	if err := db.Model(&models.Candle{}).
+		Where("period = ? AND start >= ?", volumeWindow.Interval, since).
+		Select("coin_id, SUM(volume) AS volume, SUM(trades) AS trades").
+		Group("coin_id").Order("volume DESC").Limit(LeaderboardSize).
+		Scan(&leaders).Error; err != nil {
+		return nil, fmt.Errorf("volume leaders: %w", err)
+	}
+
+	// Price only moves on trades, so the open of a coin's first candle in the
+	// window is its price when the window started
+	opens := make(map[string]map[string]units.Price, len(Windows))
+	for _, window := range Windows {
+		var rows []struct {
+			CoinID string
+			Open   units.Price
+		}
+		if err := db.Model(&models.Candle{}).
+			Where("period = ? AND start >= ?", window.Interval, windowStart(now, window)).
+			Select("DISTINCT ON (coin_id) coin_id, open").
+			Order("coin_id, start").
+			Scan(&rows).Error; err != nil {
+			return nil, fmt.Errorf("%s movers: %w", window.Name, err)
+		}
+		opens[window.Name] = make(map[string]units.Price, len(rows))
+		for _, row := range rows {
+			opens[window.Name][row.CoinID] = row.Open
+		}
+	}
+
+	ids := make(map[string]bool)
+	for _, leader := range leaders {
+		ids[leader.CoinID] = true
+	}
+	for _, byCoin := range opens {
+		for id := range byCoin {
+			ids[id] = true
+		}
+	}
+	coins, err := loadSummaries(db, ids)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, leader := range leaders {
+		if coin, ok := coins[leader.CoinID]; ok {
+			overview.TopVolume = append(overview.TopVolume, VolumeLeader{Coin: coin, Volume: leader.Volume, Trades: leader.Trades})
+		}
+	}
+	for _, window := range Windows {
+		overview.Gainers[window.Name], overview.Losers[window.Name] = rankMovers(opens[window.Name], coins)
+	}
+
+	if overview.NewCoinsPerHour, err = newCoinsPerHour(db, now); err != nil {
+		return nil, err
+	}
+	return overview, nil
+}
+
+// CoinVolume returns the SOL a coin traded over the volume window
+func CoinVolume(db *gorm.DB, coinID string, now time.Time) (units.Lamports, error) {
+	var volume units.Lamports
+	err := db.Model(&models.Candle{}).
+		Where("coin_id = ? AND period = ? AND start >= ?", coinID, volumeWindow.Interval, windowStart(now, volumeWindow)).
+		Select("COALESCE(SUM(volume), 0)").Scan(&volume).Error
+	return volume, err
+}
+
+// loadSummaries reads the leaderboard fields of the given coins
+func loadSummaries(db *gorm.DB, ids map[string]bool) (map[string]CoinSummary, error) {
+	coins := make(map[string]CoinSummary, len(ids))
+	if len(ids) == 0 {
+		return coins, nil
+	}
+	list := make([]string, 0, len(ids))
+	for id := range ids {
+		list = append(list, id)
+	}
+
+	var rows []CoinSummary
+	if err := db.Model(&models.Coin{}).
+		Select("id, name, symbol, image, price, market_cap, graduated").
+		Where("id IN ?", list).Scan(&rows).Error; err != nil {
+		return nil, fmt.Errorf("load coins: %w", err)
+	}
+	for _, row := range rows {
+		coins[row.ID] = row
+	}
+	return coins, nil
+}
+
+// rankMovers orders coins by price change since their window open, returning
+// the biggest gainers and losers, empty rather than nil
+func rankMovers(opens map[string]units.Price, coins map[string]CoinSummary) (gainers, losers []Mover) {
+	gainers, losers = []Mover{}, []Mover{}
+	for id, open := range opens {
+		coin, ok := coins[id]
+		if !ok || open <= 0 || coin.Price == open {
+			continue
+		}
+		mover := Mover{Coin: coin, PriceBefore: open, Change: (float64(coin.Price)/float64(open) - 1) * 100}
+		if mover.Change > 0 {
+			gainers = append(gainers, mover)
+		} else {
+			losers = append(losers, mover)
+		}
+	}
+	gainers = sortMovers(gainers, func(a, b float64) bool { return a > b })
+	losers = sortMovers(losers, func(a, b float64) bool { return a < b })
+	return gainers, losers
+}
+
+// sortMovers sorts by change, ties by coin ID, and keeps the top of the board
+func sortMovers(movers []Mover, before func(a, b float64) bool) []Mover {
+	sort.Slice(movers, func(i, j int) bool {
+		if movers[i].Change != movers[j].Change {
+			return before(movers[i].Change, movers[j].Change)
+		}
+		return movers[i].Coin.ID < movers[j].Coin.ID
+	})
+	if len(movers) > LeaderboardSize {
+		movers = movers[:LeaderboardSize]
+	}
+	return movers
+}
+
+// newCoinsPerHour counts launches in each of the last 24 hours, including empty ones
+func newCoinsPerHour(db *gorm.DB, now time.Time) ([]HourCount, error) {
+	first := now.UTC().Truncate(time.Hour).Add(-23 * time.Hour)
+	var rows []HourCount
+	if err := db.Model(&models.Coin{}).
+		Where("created_at >= ?", first).
+		Select("DATE_TRUNC('hour', created_at AT TIME ZONE 'UTC') AS hour, COUNT(*) AS count").
+		Group("hour").Scan(&rows).Error; err != nil {
+		return nil, fmt.Errorf("new coins: %w", err)
+	}
+
+	counts := make(map[int64]int64, len(rows))
+	for _, row := range rows {
+		counts[row.Hour.Unix()] = row.Count
+	}
+	hours := make([]HourCount, 24)
+	for i := range hours {
+		hour := first.Add(time.Duration(i) * time.Hour)
+		hours[i] = HourCount{Hour: hour, Count: counts[hour.Unix()]}
+	}
+	return hours, nil
+}
@@ -0,0 +1,92 @@
+package market
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"memepump/units"
+)
+
+func TestRankMovers(t *testing.T) {
+	coins := map[string]CoinSummary{
+		"up":     {ID: "up", Price: 300},
+		"tie-a":  {ID: "tie-a", Price: 150},
+		"tie-b":  {ID: "tie-b", Price: 150},
+		"down":   {ID: "down", Price: 25},
+		"flat":   {ID: "flat", Price: 100},
+		"dipped": {ID: "dipped", Price: 90},
+	}
+	opens := map[string]units.Price{
+		"up": 100, "tie-a": 100, "tie-b": 100, "down": 100, "flat": 100, "dipped": 100,
+		"unknown": 100, // Coin missing from the summaries
+	}
+
+	gainers, losers := rankMovers(opens, coins)
+	if got := moverIDs(gainers); got != "[up tie-a tie-b]" {
+		t.Errorf("gainers = %s; want [up tie-a tie-b]", got)
+	}
+	if got := moverIDs(losers); got != "[down dipped]" {
+		t.Errorf("losers = %s; want [down dipped]", got)
+	}
+	if gainers[0].Change != 200 || gainers[0].PriceBefore != 100 {
+		t.Errorf("up moved %+v; want +200%% from 100", gainers[0])
+	}
+	if losers[0].Change != -75 {
+		t.Errorf("down changed %.2f%%; want -75%%", losers[0].Change)
+	}
+}
+
+func TestRankMoversKeepsLeaderboardSize(t *testing.T) {
+	coins := make(map[string]CoinSummary)
+	opens := make(map[string]units.Price)
+	for i := 0; i < 3*LeaderboardSize; i++ {
+		id := fmt.Sprintf("coin-%02d", i)
+		coins[id] = CoinSummary{ID: id, Price: units.Price(200 + i)}
+		opens[id] = 100
+	}
+	gainers, _ := rankMovers(opens, coins)
+	if len(gainers) != LeaderboardSize {
+		t.Fatalf("%d gainers; want %d", len(gainers), LeaderboardSize)
+	}
+	if gainers[0].Coin.ID != fmt.Sprintf("coin-%02d", 3*LeaderboardSize-1) {
+		t.Errorf("top gainer %s; want the biggest rise", gainers[0].Coin.ID)
+	}
+}
+
+func TestWindowStartAlignsToCandles(t *testing.T) {
+	now := time.Date(2026, 3, 14, 3, 47, 31, 0, time.UTC)
+	want := map[string]time.Time{
+		"5m":  time.Date(2026, 3, 14, 3, 42, 0, 0, time.UTC),
+		"1h":  time.Date(2026, 3, 14, 2, 47, 0, 0, time.UTC),
+		"24h": time.Date(2026, 3, 13, 3, 45, 0, 0, time.UTC),
+	}
+	for _, window := range Windows {
+		if got := windowStart(now, window); !got.Equal(want[window.Name]) {
+			t.Errorf("%s window starts at %s; want %s", window.Name, got, want[window.Name])
+		}
+	}
+}
+
+func TestEmptyLeaderboardsAreNotNull(t *testing.T) {
+	gainers, losers := rankMovers(nil, nil)
+	data, err := json.Marshal(Overview{Gainers: map[string][]Mover{"5m": gainers}, Losers: map[string][]Mover{"5m": losers}, TopVolume: []VolumeLeader{}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{`"gainers":{"5m":[]}`, `"losers":{"5m":[]}`, `"topVolume":[]`} {
+		if !strings.Contains(string(data), want) {
+			t.Errorf("overview %s; want %s", data, want)
+		}
+	}
+}
+
+func moverIDs(movers []Mover) string {
+	ids := make([]string, len(movers))
+	for i, mover := range movers {
+		ids[i] = mover.Coin.ID
+	}
+	return fmt.Sprint(ids)
+}
@@ -0,0 +1,231 @@
+package middleware
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"log"
+	"net/http"
+	"time"
+
+	"memepump/database"
+	"memepump/models"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// IdempotencyHeader carries the client's deduplication key
+const IdempotencyHeader = "Idempotency-Key"
+
+// ReplayedHeader marks a response replayed from an earlier request
+const ReplayedHeader = "Idempotent-Replayed"
+
+// MaxIdempotencyKeyLength bounds client keys
+const MaxIdempotencyKeyLength = 255
+
+// Idempotency key statuses
+const (
+	idempotencyPending   = "pending"
+	idempotencyCompleted = "completed"
+)
+
+// IdempotencyWindow is how long keys are remembered, overridable through IDEMPOTENCY_WINDOW
+var IdempotencyWindow = 24 * time.Hour
+
+// IdempotencyLease is how long a pending key is held for the request that claimed
+// it, overridable through IDEMPOTENCY_LEASE. A retry after the lease lapses takes
+// the key over, so a crash between claim and completion does not lock the key for
+// the whole window.
+var IdempotencyLease = time.Minute
+
+// cachedResponse is the Redis copy of a completed key
+type cachedResponse struct {
+	RequestHash string `json:"requestHash"`
+	StatusCode  int    `json:"statusCode"`
+	Response    string `json:"response"`
+}
+
+type responseRecorder struct {
+	gin.ResponseWriter
+	body bytes.Buffer
+}
+
+func (w *responseRecorder) Write(b []byte) (int, error) {
+	w.body.Write(b)
+	return w.ResponseWriter.Write(b)
+}
+
+func (w *responseRecorder) WriteString(s string) (int, error) {
+	w.body.WriteString(s)
+	return w.ResponseWriter.WriteString(s)
+}
+
+// IdempotencyMiddleware makes a POST safe to retry. The first request with an
+// Idempotency-Key claims the key in Postgres before the handler runs; its response
+// is stored there and cached in Redis, and retries within IdempotencyWindow get it
+// back instead of running the handler again. A retry while the first request is
+// still running gets 409 until IdempotencyLease lapses, and reusing a key with a
+// different body gets 422. Requests without the header pass through. Must run
+// after AuthMiddleware.
+func IdempotencyMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		clientKey := c.GetHeader(IdempotencyHeader)
+		if clientKey == "" {
+			c.Next()
+			return
+		}
+		if len(clientKey) > MaxIdempotencyKeyLength {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Idempotency-Key is too long"})
+			c.Abort()
+			return
+		}
+
+		body, err := io.ReadAll(c.Request.Body)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Failed to read request body"})
+			c.Abort()
+			return
+		}
+		c.Request.Body = io.NopCloser(bytes.NewReader(body))
+		sum := sha256.Sum256(body)
+		requestHash := hex.EncodeToString(sum[:])
+
+		// Keys are scoped to the user and route, so clients cannot collide
+		key := c.GetString("userID") + ":" + c.Request.Method + " " + c.FullPath() + ":" + clientKey
+
+		if cached, ok := cachedIdempotentResponse(key); ok {
+			replay(c, cached, requestHash)
+			return
+		}
+
+		now := time.Now()
+		claim, existing, err := claimIdempotencyKey(key, requestHash, now)
+		if err != nil {
+			log.Println("Idempotency key claim failed:", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check Idempotency-Key"})
+			c.Abort()
+			return
+		}
+		if claim == "" {
+			if existing.Status != idempotencyCompleted {
+				c.JSON(http.StatusConflict, gin.H{"error": "A request with this Idempotency-Key is still in progress"})
+				c.Abort()
+				return
+			}
+			replay(c, &cachedResponse{
+				RequestHash: existing.RequestHash,
+				StatusCode:  existing.StatusCode,
+				Response:    existing.Response,
+			}, requestHash)
+			return
+		}
+
+		recorder := &responseRecorder{ResponseWriter: c.Writer}
+		c.Writer = recorder
+		c.Next()
+
+		storeIdempotentResponse(key, claim, requestHash, recorder.Status(), recorder.body.String())
+	}
+}
+
+// claimIdempotencyKey inserts a pending key and returns the claim token that
+// completes it, or returns the live key already there. Expired keys and pending
+// keys whose lease lapsed are replaced.
+func claimIdempotencyKey(key, requestHash string, now time.Time) (string, *models.IdempotencyKey, error) {
+	var existing models.IdempotencyKey
+	claim := ""
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("key = ? AND (expires_at <= ? OR (status = ? AND locked_until <= ?))",
+			key, now, idempotencyPending, now).Delete(&models.IdempotencyKey{}).Error; err != nil {
+			return err
+		}
+		entry := models.IdempotencyKey{
+			Key:         key,
+			RequestHash: requestHash,
+			Status:      idempotencyPending,
+			CreatedAt:   now,
+			ExpiresAt:   now.Add(IdempotencyWindow),
+			Claim:       uuid.New().String(),
+			LockedUntil: now.Add(IdempotencyLease),
+		}
+		result := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&entry)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 1 {
+			claim = entry.Claim
+			return nil
+		}
+		return tx.First(&existing, "key = ?", key).Error
+	})
+	return claim, &existing, err
+}
+
+// storeIdempotentResponse completes the key with the handler's response. Server
+// errors release it instead, so the client can retry a request that did nothing.
+// Only the holder of the claim may do either; a key taken over after its lease
+// lapsed belongs to the retry.
+func storeIdempotentResponse(key, claim, requestHash string, status int, body string) {
+	if status >= http.StatusInternalServerError {
+		if err := database.DB.Delete(&models.IdempotencyKey{}, "key = ? AND claim = ?", key, claim).Error; err != nil {
+			log.Println("Failed to release Idempotency-Key:", err)
+		}
+		return
+	}
+
+	result := database.DB.Model(&models.IdempotencyKey{}).Where("key = ? AND claim = ?", key, claim).Updates(map[string]interface{}{
+		"status":      idempotencyCompleted,
+		"status_code": status,
+		"response":    body,
+	})
+	if result.Error != nil {
+		// The key stays pending until its lease lapses, so retries wait rather than run twice
+		log.Println("Failed to store idempotent response:", result.Error)
+		return
+	}
+	if result.RowsAffected == 0 {
+		log.Println("Idempotency-Key lease lapsed before the response was stored:", key)
+		return
+	}
+
+	if database.RDB == nil {
+		return
+	}
+	data, err := json.Marshal(cachedResponse{RequestHash: requestHash, StatusCode: status, Response: body})
+	if err == nil {
+		database.RDB.Set(database.Ctx, "idempotency:"+key, data, IdempotencyWindow)
+	}
+}
+
+// cachedIdempotentResponse reads a completed key from Redis
+func cachedIdempotentResponse(key string) (*cachedResponse, bool) {
+	if database.RDB == nil {
+		return nil, false
+	}
+	val, err := database.RDB.Get(database.Ctx, "idempotency:"+key).Bytes()
+	if err != nil {
+		return nil, false
+	}
+	var cached cachedResponse
+	if err := json.Unmarshal(val, &cached); err != nil {
+		return nil, false
+	}
+	return &cached, true
+}
+
+// replay writes a stored response, refusing a key reused for a different request
+func replay(c *gin.Context, cached *cachedResponse, requestHash string) {
+	if cached.RequestHash != requestHash {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": "Idempotency-Key was already used with a different request"})
+		c.Abort()
+		return
+	}
+	c.Header(ReplayedHeader, "true")
+	c.Data(cached.StatusCode, "application/json; charset=utf-8", []byte(cached.Response))
+	c.Abort()
+}
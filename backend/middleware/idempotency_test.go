@@ -0,0 +1,119 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"memepump/database"
+	"memepump/models"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+func TestReplay(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	cached := &cachedResponse{RequestHash: "abc", StatusCode: http.StatusOK, Response: `{"trade":{"id":"t1"}}`}
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	replay(c, cached, "abc")
+	if w.Code != http.StatusOK || w.Body.String() != cached.Response || w.Header().Get(ReplayedHeader) != "true" {
+		t.Errorf("replay = %d %q; want the stored response", w.Code, w.Body.String())
+	}
+
+	w = httptest.NewRecorder()
+	c, _ = gin.CreateTestContext(w)
+	replay(c, cached, "other body")
+	if w.Code != http.StatusUnprocessableEntity {
+		t.Errorf("replay with a different body = %d; want %d", w.Code, http.StatusUnprocessableEntity)
+	}
+}
+
+// testDB connects to the Postgres at TEST_DATABASE_URL, skipping the test without one
+func testDB(t *testing.T) {
+	dsn := os.Getenv("TEST_DATABASE_URL")
+	if dsn == "" {
+		t.Skip("TEST_DATABASE_URL not set")
+	}
+	db, err := gorm.Open(postgres.Open(dsn), &gorm.Config{Logger: logger.Default.LogMode(logger.Silent)})
+	if err != nil {
+		t.Fatalf("connect: %v", err)
+	}
+	if err := db.AutoMigrate(&models.IdempotencyKey{}); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+	previous, previousRDB := database.DB, database.RDB
+	database.DB, database.RDB = db, nil
+	t.Cleanup(func() { database.DB, database.RDB = previous, previousRDB })
+}
+
+func TestIdempotencyMiddleware(t *testing.T) {
+	testDB(t)
+	gin.SetMode(gin.TestMode)
+
+	userID := "idempotency-test-" + uuid.New().String()
+	t.Cleanup(func() { database.DB.Where("key LIKE ?", userID+":%").Delete(&models.IdempotencyKey{}) })
+
+	runs := 0
+	router := gin.New()
+	router.POST("/trade", func(c *gin.Context) { c.Set("userID", userID) }, IdempotencyMiddleware(), func(c *gin.Context) {
+		runs++
+		c.JSON(http.StatusCreated, gin.H{"run": runs})
+	})
+	post := func(clientKey, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/trade", strings.NewReader(body))
+		req.Header.Set(IdempotencyHeader, clientKey)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	// Claim, then replay
+	first := post("k1", `{"amount":1}`)
+	if first.Code != http.StatusCreated || runs != 1 {
+		t.Fatalf("first request = %d after %d runs; want 201 after 1", first.Code, runs)
+	}
+	retry := post("k1", `{"amount":1}`)
+	if retry.Code != http.StatusCreated || retry.Body.String() != first.Body.String() || retry.Header().Get(ReplayedHeader) != "true" {
+		t.Errorf("retry = %d %q; want the first response replayed", retry.Code, retry.Body.String())
+	}
+	if post("k1", `{"amount":2}`).Code != http.StatusUnprocessableEntity {
+		t.Error("key reused with a different body was not refused")
+	}
+	if runs != 1 {
+		t.Errorf("handler ran %d times; want 1", runs)
+	}
+
+	// A pending key conflicts while its lease holds
+	key := userID + ":POST /trade:k2"
+	now := time.Now()
+	claim, _, err := claimIdempotencyKey(key, "hash", now)
+	if err != nil || claim == "" {
+		t.Fatalf("claim = %q, %v; want a claim", claim, err)
+	}
+	if post("k2", "hash").Code != http.StatusConflict {
+		t.Error("retry of a pending key was not refused")
+	}
+
+	// Once the lease lapses a retry takes the key over, and the stale holder cannot complete it
+	database.DB.Model(&models.IdempotencyKey{}).Where("key = ?", key).Update("locked_until", now.Add(-time.Second))
+	if w := post("k2", `{}`); w.Code != http.StatusCreated || runs != 2 {
+		t.Errorf("retry after the lease = %d after %d runs; want 201 after 2", w.Code, runs)
+	}
+	storeIdempotentResponse(key, claim, "hash", http.StatusOK, `{"stale":true}`)
+	var stored models.IdempotencyKey
+	if err := database.DB.First(&stored, "key = ?", key).Error; err != nil {
+		t.Fatalf("load key: %v", err)
+	}
+	if stored.Status != idempotencyCompleted || stored.StatusCode != http.StatusCreated || stored.Claim == claim {
+		t.Errorf("key = %s %d; want completed by the retry with 201", stored.Status, stored.StatusCode)
+	}
+}
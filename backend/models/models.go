@@ -1,25 +1,33 @@
 package models
 
 import (
+	"encoding/json"
 	"time"
+
+	"memepump/units"
 )
 
 type Coin struct {
-	ID          string    `json:"id" gorm:"primaryKey"`
-	Name        string    `json:"name"`
-	Symbol      string    `json:"symbol"`
-	Description string    `json:"description"`
-	Image       string    `json:"image"`
-	Creator     string    `json:"creator"`
-	Twitter     string    `json:"twitter"`
-	Telegram    string    `json:"telegram"`
-	Website     string    `json:"website"`
-	MarketCap   float64   `json:"marketCap"`
-	Progress    float64   `json:"progress"`
-	TotalSupply float64   `json:"totalSupply"`
-	Price       float64   `json:"price"`
-	CreatedAt   time.Time `json:"createdAt"`
-	Holders     int       `json:"holders"`
+	ID          string         `json:"id" gorm:"primaryKey"`
+	Name        string         `json:"name"`
+	Symbol      string         `json:"symbol"`
+	Description string         `json:"description"`
+	Image       string         `json:"image"`
+	Creator     string         `json:"creator"` // Username of the creator at launch, for display
+	Twitter     string         `json:"twitter"`
+	Telegram    string         `json:"telegram"`
+	Website     string         `json:"website"`
+	MarketCap   units.Lamports `json:"marketCap"`
+	Progress    float64        `json:"progress"`
+	TotalSupply units.Tokens   `json:"totalSupply"` // Circulating supply sold from the curve
+	Price       units.Price    `json:"price"`
+	CreatedAt   time.Time      `json:"createdAt"`
+	Holders     int            `json:"holders"`
+	TradeSeq    int64          `json:"tradeSeq"` // Seq of the coin's latest trade
+
+	LiquiditySeq int64 `json:"liquiditySeq"` // Seq of the coin's latest liquidity event
+
+	CreatorUserID string `json:"creatorUserId" gorm:"index"` // Authenticated user who launched the coin
 
 	// Blockchain Integration
 	MintAddress   string `json:"mintAddress"`   // SPL Token or ERC20 address
@@ -28,19 +36,34 @@ type Coin struct {
 	TxHash        string `json:"txHash"`        // Creation transaction hash
 
 	// Bonding Curve Configuration
-	CurveType  string  `json:"curveType"`  // "exponential", "linear", "constant_product"
-	CurveK     float64 `json:"curveK"`     // Curve steepness parameter
-	CurveSlope float64 `json:"curveSlope"` // Linear slope (for linear curves)
-	BasePrice  float64 `json:"basePrice"`  // Starting price
+	CurveType   string         `json:"curveType"`          // Registered curve type, e.g. "exponential"
+	CurveK      float64        `json:"curveK"`             // Curve steepness parameter
+	CurveSlope  float64        `json:"curveSlope"`         // Linear slope (for linear curves)
+	CurveParams string         `json:"-" gorm:"type:text"` // JSON params for curve types that need them
+	BasePrice   units.Price    `json:"basePrice"`          // Starting price
+	MaxSupply   units.Tokens   `json:"maxSupply"`          // Curve supply cap
+	TargetMcap  units.Lamports `json:"targetMcap"`         // Market cap that triggers graduation
+
+	// Reserves: real reserves for every curve, virtual ones for virtual_amm curves
+	RealSolReserves      units.Lamports `json:"realSolReserves"`      // SOL paid into the curve
+	RealTokenReserves    units.Tokens   `json:"realTokenReserves"`    // Tokens left to sell before graduation
+	VirtualSolReserves   units.Lamports `json:"virtualSolReserves"`   // x in x * y = k
+	VirtualTokenReserves units.Tokens   `json:"virtualTokenReserves"` // y in x * y = k
 
 	// IPFS / Decentralized Storage
 	IPFSHash     string `json:"ipfsHash"`     // Token metadata IPFS CID
 	IPFSImageCID string `json:"ipfsImageCid"` // Image IPFS CID
 
 	// Liquidity Lock (Rug-Pull Protection)
-	LiquidityLocked bool      `json:"liquidityLocked"`
-	LockUntil       time.Time `json:"lockUntil"`
-	LockedAmount    float64   `json:"lockedAmount"`
+	LiquidityLocked bool           `json:"liquidityLocked"`
+	LockUntil       time.Time      `json:"lockUntil"`
+	LockedAmount    units.Lamports `json:"lockedAmount"`
+
+	// Anti-sniper rules for the first minutes after launch
+	LaunchRules LaunchRules `json:"launchRules" gorm:"embedded;embeddedPrefix:launch_"`
+
+	// Share of MaxSupply one user's wallets may hold while on the curve, 0 for no cap
+	MaxHoldingBps int64 `json:"maxHoldingBps"`
 
 	// Graduation (when hitting bonding curve target)
 	Graduated   bool      `json:"graduated"` // Listed on DEX
@@ -48,6 +71,23 @@ type Coin struct {
 	PoolAddress string    `json:"poolAddress"` // Raydium/Uniswap pool
 }
 
+// LaunchRules protect a new coin from snipers. Windows count from the coin's
+// creation and the creator's wallet is exempt.
+type LaunchRules struct {
+	Window          int64          `json:"windowSeconds"`                // Seconds after launch the buy cap and cooldown apply
+	MaxWalletBuy    units.Lamports `json:"maxWalletBuy"`                 // SOL a wallet may spend in the window, fees included; 0 for no cap
+	Cooldown        int64          `json:"cooldownSeconds"`              // Seconds a wallet must wait between buys in the window
+	AllowlistWindow int64          `json:"allowlistSeconds"`             // Seconds after launch only allowlisted wallets may buy
+	AllowlistSize   int            `json:"allowlistSize"`                // Wallets on the allowlist
+	Allowlist       []string       `json:"allowlist,omitempty" gorm:"-"` // Only read from the create request
+}
+
+// LaunchAllowlistEntry lets a wallet buy during a coin's allowlist phase
+type LaunchAllowlistEntry struct {
+	CoinID string `json:"coinId" gorm:"primaryKey"`
+	Wallet string `json:"wallet" gorm:"primaryKey"`
+}
+
 // WalletLink connects a user account to blockchain wallets
 type WalletLink struct {
 	ID        string    `json:"id" gorm:"primaryKey"`
@@ -60,31 +100,242 @@ type WalletLink struct {
 
 // CurveParams defines bonding curve configuration
 type CurveParams struct {
-	Type       string  `json:"type"`       // "exponential", "linear", "constant_product"
-	K          float64 `json:"k"`          // Steepness for exponential
-	Slope      float64 `json:"slope"`      // Slope for linear
-	BasePrice  float64 `json:"basePrice"`  // Starting price
-	MaxSupply  float64 `json:"maxSupply"`  // Target supply for graduation
-	TargetMcap float64 `json:"targetMcap"` // Target market cap for graduation
+	Type       string          `json:"type"`       // Any registered curve type
+	K          float64         `json:"k"`          // Steepness for exponential
+	Slope      float64         `json:"slope"`      // Slope for linear
+	Params     json.RawMessage `json:"params"`     // Type-specific params for newer curves
+	BasePrice  units.Price     `json:"basePrice"`  // Starting price
+	MaxSupply  units.Tokens    `json:"maxSupply"`  // Target supply for graduation
+	TargetMcap units.Lamports  `json:"targetMcap"` // Target market cap for graduation
 }
 
 type Trade struct {
-	ID        string    `json:"id" gorm:"primaryKey"`
-	CoinID    string    `json:"coinId" gorm:"index"`
-	Type      string    `json:"type"` // "buy" or "sell"
-	Amount    float64   `json:"amount"`
-	Price     float64   `json:"price"`
+	ID        string         `json:"id" gorm:"primaryKey"`
+	CoinID    string         `json:"coinId" gorm:"index;uniqueIndex:idx_trades_coin_seq_unique,priority:1,where:seq > 0"`
+	Type      string         `json:"type"`      // "buy" or "sell"
+	Venue     string         `json:"venue"`     // "curve", or "pool" after graduation
+	Amount    units.Tokens   `json:"amount"`    // Tokens bought or sold
+	SolAmount units.Lamports `json:"solAmount"` // Curve cost (buy) or return (sell), before fees
+	Price     units.Price    `json:"price"`     // Average fill price
+
+	// Position in the coin's trade sequence, from 1. Trades stored before sequence
+	// numbers existed hold 0 until the startup backfill numbers them.
+	Seq int64 `json:"seq" gorm:"uniqueIndex:idx_trades_coin_seq_unique,priority:2"`
+
+	// Spot prices around the fill; zero on trades stored before they were recorded
+	PriceBefore units.Price `json:"priceBefore"`
+	PriceAfter  units.Price `json:"priceAfter"`
+
+	PlatformFee units.Lamports `json:"platformFee"`
+	CreatorFee  units.Lamports `json:"creatorFee"`
+
 	Wallet    string    `json:"wallet"`
+	UserID    string    `json:"userId" gorm:"index"`
 	Username  string    `json:"username"`
 	Timestamp time.Time `json:"timestamp"`
 
 	// Blockchain Transaction Details
-	TxHash      string  `json:"txHash"`      // On-chain transaction hash
-	Signature   string  `json:"signature"`   // Transaction signature
-	BlockNumber uint64  `json:"blockNumber"` // Block number
-	GasFee      float64 `json:"gasFee"`      // Transaction fee paid
-	ChainID     string  `json:"chainId"`     // Which chain this trade occurred on
-	Status      string  `json:"status"`      // "pending", "confirmed", "failed"
+	TxHash      string         `json:"txHash"`      // On-chain transaction hash
+	Signature   string         `json:"signature"`   // Transaction signature
+	BlockNumber uint64         `json:"blockNumber"` // Block number
+	GasFee      units.Lamports `json:"gasFee"`      // Transaction fee paid: platform plus creator fee
+	ChainID     string         `json:"chainId"`     // Which chain this trade occurred on
+	Status      string         `json:"status"`      // "pending", "confirmed", "failed"
+}
+
+// Balance is a wallet's holding of one coin, updated in the trade transaction.
+// A wallet's balances belong to the user who first traded with it.
+type Balance struct {
+	Wallet    string         `json:"wallet" gorm:"primaryKey"`
+	CoinID    string         `json:"coinId" gorm:"primaryKey;index"`
+	UserID    string         `json:"userId" gorm:"index"`
+	Amount    units.Tokens   `json:"amount"`
+	Reserved  units.Tokens   `json:"reserved"`  // Held for open sell orders
+	CostBasis units.Lamports `json:"costBasis"` // SOL paid for the tokens still held, fees included
+	UpdatedAt time.Time      `json:"updatedAt"`
+}
+
+// SolAccount is a user's internal SOL balance that pays for trades
+type SolAccount struct {
+	UserID    string         `json:"userId" gorm:"primaryKey"`
+	Balance   units.Lamports `json:"balance"`
+	UpdatedAt time.Time      `json:"updatedAt"`
+}
+
+// SolTransaction is an entry in a SOL account's history
+type SolTransaction struct {
+	ID           string         `json:"id" gorm:"primaryKey"`
+	UserID       string         `json:"userId" gorm:"index"`
+	Kind         string         `json:"kind"`         // "deposit", "withdrawal", "buy", "sell", "fee_claim", "lp_add", "lp_remove", "order_reserve", "order_release"
+	Amount       units.Lamports `json:"amount"`       // Signed: credits positive, debits negative
+	BalanceAfter units.Lamports `json:"balanceAfter"` // Account balance once applied
+	TradeID      string         `json:"tradeId,omitempty" gorm:"index"`
+	OrderID      string         `json:"orderId,omitempty" gorm:"index"`
+	Signature    string         `json:"signature,omitempty" gorm:"uniqueIndex:idx_sol_tx_signature,where:signature <> ''"` // On-chain deposit or payout
+	Address      string         `json:"address,omitempty"`                                                                 // Deposit source or withdrawal destination
+	Status       string         `json:"status"`                                                                            // "completed" or "pending" (withdrawals awaiting payout)
+	CreatedAt    time.Time      `json:"createdAt"`
+}
+
+// MigrationJob moves a graduated coin's liquidity to a DEX pool
+type MigrationJob struct {
+	ID             string         `json:"id" gorm:"primaryKey"`
+	CoinID         string         `json:"coinId" gorm:"uniqueIndex"`
+	Status         string         `json:"status" gorm:"index"` // "pending", "running", "done", "failed"
+	Adapter        string         `json:"adapter"`             // DEX adapter that created the pool
+	SolLiquidity   units.Lamports `json:"solLiquidity"`
+	TokenLiquidity units.Tokens   `json:"tokenLiquidity"`
+	Attempts       int            `json:"attempts"`
+	NextAttemptAt  time.Time      `json:"nextAttemptAt" gorm:"index"`
+	PoolAddress    string         `json:"poolAddress"`
+	TxHash         string         `json:"txHash"`
+	LastError      string         `json:"lastError"`
+	CreatedAt      time.Time      `json:"createdAt"`
+	UpdatedAt      time.Time      `json:"updatedAt"`
+}
+
+// Pool is the in-process constant-product pool a coin trades on after graduation
+type Pool struct {
+	CoinID       string         `json:"coinId" gorm:"primaryKey"`
+	SolReserve   units.Lamports `json:"solReserve"`
+	TokenReserve units.Tokens   `json:"tokenReserve"`
+	LPSupply     int64          `json:"lpSupply,string"` // Outstanding LP shares
+	FeeBps       int64          `json:"feeBps"`          // Swap fee kept in the pool for LPs
+	CreatedAt    time.Time      `json:"createdAt"`
+	UpdatedAt    time.Time      `json:"updatedAt"`
+}
+
+// LPPosition is an owner's share of a pool
+type LPPosition struct {
+	CoinID    string    `json:"coinId" gorm:"primaryKey"`
+	Owner     string    `json:"owner" gorm:"primaryKey"` // User ID, or "platform" for the graduation seed
+	Shares    int64     `json:"shares,string"`
+	UpdatedAt time.Time `json:"updatedAt"`
+}
+
+// Candle is a coin's OHLCV summary for one interval, kept up to date by every trade
+type Candle struct {
+	CoinID      string         `json:"coinId" gorm:"primaryKey"`
+	Interval    string         `json:"interval" gorm:"primaryKey;column:period"` // "1s", "1m", "5m", "15m", "1h", "4h" or "1d"
+	Start       time.Time      `json:"start" gorm:"primaryKey"`                  // UTC start of the interval
+	Open        units.Price    `json:"open"`
+	High        units.Price    `json:"high"`
+	Low         units.Price    `json:"low"`
+	Close       units.Price    `json:"close"`
+	Volume      units.Lamports `json:"volume"`      // SOL traded, before fees
+	TokenVolume units.Tokens   `json:"tokenVolume"` // Tokens traded
+	Trades      int64          `json:"trades"`
+}
+
+// LiquidityEvent records liquidity added to or removed from a graduated coin's
+// pool. Together with the coin's trades it forms the log its state is folded from.
+type LiquidityEvent struct {
+	ID        string         `json:"id" gorm:"primaryKey"`
+	CoinID    string         `json:"coinId" gorm:"uniqueIndex:idx_liquidity_events_coin_seq_unique,priority:1"`
+	Seq       int64          `json:"seq" gorm:"uniqueIndex:idx_liquidity_events_coin_seq_unique,priority:2"` // Position among the coin's liquidity events, from 1
+	TradeSeq  int64          `json:"tradeSeq"`                                                               // The coin's latest trade when it happened
+	Action    string         `json:"action"`                                                                 // "add" or "remove"
+	UserID    string         `json:"userId" gorm:"index"`
+	Wallet    string         `json:"wallet"`
+	Sol       units.Lamports `json:"sol"`
+	Tokens    units.Tokens   `json:"tokens"`
+	Shares    int64          `json:"shares,string"`
+	CreatedAt time.Time      `json:"createdAt"`
+}
+
+// Order is a resting order that trades once the coin's price crosses its trigger.
+// Limit buys reserve their SOL budget and limit sells their tokens until filled or
+// cancelled; position rules sell from the position as it stands when they trigger.
+type Order struct {
+	ID           string         `json:"id" gorm:"primaryKey"`
+	UserID       string         `json:"userId" gorm:"index"`
+	Username     string         `json:"username"`
+	CoinID       string         `json:"coinId" gorm:"index:idx_orders_coin_status"`
+	Status       string         `json:"status" gorm:"index:idx_orders_coin_status"` // "open", "filled", "cancelled", "failed"
+	Type         string         `json:"type"`                                       // "limit", "stop_loss", "take_profit" or "trailing_stop"
+	Side         string         `json:"side"`                                       // "buy" or "sell"
+	Wallet       string         `json:"wallet"`
+	TriggerPrice units.Price    `json:"triggerPrice"` // Limit buys fill at or below it, limit sells and take-profits at or above, stops at or below
+	SolAmount    units.Lamports `json:"solAmount"`    // Budget of a buy, fees included
+	Amount       units.Tokens   `json:"amount"`       // Tokens a sell offers; 0 sells a rule's whole position
+	TradeID      string         `json:"tradeId,omitempty"`
+	Error        string         `json:"error,omitempty"` // Why a triggered order failed
+	CreatedAt    time.Time      `json:"createdAt"`
+	UpdatedAt    time.Time      `json:"updatedAt"`
+	FilledAt     *time.Time     `json:"filledAt,omitempty"`
+
+	// Position rules: stop-loss, take-profit and trailing stops
+	TriggerPercent float64     `json:"triggerPercent,omitempty"` // Distance from the average entry, or the trail below the peak
+	PeakPrice      units.Price `json:"peakPrice,omitempty"`      // Highest price a trailing stop has seen
+	SlippageBps    int64       `json:"slippageBps,omitempty"`    // How far below spot value a rule's sell may return, fees included
+}
+
+// DCAPlan buys a fixed amount of SOL worth of a coin on a schedule
+type DCAPlan struct {
+	ID        string         `json:"id" gorm:"primaryKey"`
+	UserID    string         `json:"userId" gorm:"index"`
+	Username  string         `json:"username"`
+	CoinID    string         `json:"coinId" gorm:"index"`
+	Wallet    string         `json:"wallet"`
+	SolAmount units.Lamports `json:"solAmount"`                                // Spent per run, fees included
+	Interval  int64          `json:"intervalSeconds"`                          // Seconds between runs
+	TotalRuns int            `json:"totalRuns"`                                // Runs before the plan completes
+	RunsDone  int            `json:"runsDone"`                                 // Runs recorded, filled or failed
+	Status    string         `json:"status" gorm:"index:idx_dca_plans_due"`    // "active", "paused", "cancelled", "completed"
+	NextRunAt time.Time      `json:"nextRunAt" gorm:"index:idx_dca_plans_due"` // When the next run is due
+	CreatedAt time.Time      `json:"createdAt"`
+	UpdatedAt time.Time      `json:"updatedAt"`
+}
+
+// DCARun records one run of a DCA plan. A plan's run numbers are unique,
+// so a run is never recorded, or bought, twice.
+type DCARun struct {
+	ID        string    `json:"id" gorm:"primaryKey"`
+	PlanID    string    `json:"planId" gorm:"uniqueIndex:idx_dca_runs_plan_seq"`
+	Seq       int       `json:"seq" gorm:"uniqueIndex:idx_dca_runs_plan_seq"` // 1-based run number
+	Status    string    `json:"status"`                                       // "filled" or "failed"
+	TradeID   string    `json:"tradeId,omitempty"`
+	Error     string    `json:"error,omitempty"` // Why the run's buy failed
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+// IdempotencyKey holds the first response to a request sent with an
+// Idempotency-Key header, replayed to retries within the window
+type IdempotencyKey struct {
+	Key         string    `json:"key" gorm:"primaryKey"` // User, route and client key
+	RequestHash string    `json:"requestHash"`           // SHA-256 of the request body
+	Status      string    `json:"status"`                // "pending" until the response is stored, then "completed"
+	StatusCode  int       `json:"statusCode"`
+	Response    string    `json:"response" gorm:"type:text"`
+	CreatedAt   time.Time `json:"createdAt"`
+	ExpiresAt   time.Time `json:"expiresAt" gorm:"index"`
+
+	// A pending key is held by one request; a retry may take it over once the lease lapses
+	Claim       string    `json:"-"`
+	LockedUntil time.Time `json:"lockedUntil"`
+}
+
+// LockEvent records a liquidity lock or extension
+type LockEvent struct {
+	ID           string         `json:"id" gorm:"primaryKey"`
+	CoinID       string         `json:"coinId" gorm:"index"`
+	Action       string         `json:"action"` // "lock" or "extend"
+	Actor        string         `json:"actor"`  // User ID of the creator, or "graduation"
+	LockUntil    time.Time      `json:"lockUntil"`
+	LockedAmount units.Lamports `json:"lockedAmount"`
+	CreatedAt    time.Time      `json:"createdAt"`
+}
+
+// FeeEntry is a row in the fee ledger. Creator fees accrue per creator user
+// and are paid out by claim entries with negative amounts.
+type FeeEntry struct {
+	ID            string         `json:"id" gorm:"primaryKey"`
+	Kind          string         `json:"kind" gorm:"index"` // "platform", "creator" or "claim"
+	CoinID        string         `json:"coinId" gorm:"index"`
+	TradeID       string         `json:"tradeId" gorm:"index"`
+	CreatorUserID string         `json:"creatorUserId" gorm:"index"` // User the fee accrues to
+	Amount        units.Lamports `json:"amount"`
+	CreatedAt     time.Time      `json:"createdAt"`
 }
 
 type Comment struct {
@@ -112,23 +363,34 @@ type User struct {
 
 // Responses/Requests can stay here or in main, but better here for cleaner imports
 type CreateCoinRequest struct {
-	Name             string  `json:"name" binding:"required"`
-	Symbol           string  `json:"symbol" binding:"required"`
-	Description      string  `json:"description" binding:"required"`
-	Image            string  `json:"image" binding:"required"`
-	Creator          string  `json:"creator" binding:"required"`
-	Twitter          string  `json:"twitter"`
-	Telegram         string  `json:"telegram"`
-	Website          string  `json:"website"`
-	InitialBuyAmount float64 `json:"initialBuyAmount"`
+	Name             string       `json:"name" binding:"required"`
+	Symbol           string       `json:"symbol" binding:"required"`
+	Description      string       `json:"description" binding:"required"`
+	Image            string       `json:"image" binding:"required"`
+	Twitter          string       `json:"twitter"`
+	Telegram         string       `json:"telegram"`
+	Website          string       `json:"website"`
+	CreatorWallet    string       `json:"creatorWallet"`    // Receives the initial buy, defaults to the primary linked wallet
+	InitialBuyAmount units.Tokens `json:"initialBuyAmount"` // Tokens bought by the creator at launch
+	Curve            *CurveParams `json:"curve"`            // Optional, defaults to the platform curve
+	LaunchRules      *LaunchRules `json:"launchRules"`      // Optional anti-sniper rules
+	MaxHoldingBps    int64        `json:"maxHoldingBps"`    // Optional holding cap, defaults to the platform's
 }
 
 type TradeRequest struct {
-	CoinID   string  `json:"coinId" binding:"required"`
-	Type     string  `json:"type" binding:"required"`
-	Amount   float64 `json:"amount" binding:"required"`
-	Wallet   string  `json:"wallet" binding:"required"`
-	Username string  `json:"username"`
+	CoinID    string         `json:"coinId" binding:"required"`
+	Type      string         `json:"type" binding:"required"`
+	Amount    units.Tokens   `json:"amount"`    // Token amount
+	SolAmount units.Lamports `json:"solAmount"` // SOL to spend instead of a token amount (buys only)
+	Wallet    string         `json:"wallet" binding:"required"`
+	Username  string         `json:"username"`
+	UserID    string         `json:"-"` // Set from the auth token, never from the body
+
+	// Slippage protection, checked against the fill while the coin row is locked
+	MaxCost        units.Lamports `json:"maxCost"`        // Max SOL a buy may cost, fees included
+	MinTokensOut   units.Tokens   `json:"minTokensOut"`   // Min tokens a buy must return
+	MinReturn      units.Lamports `json:"minReturn"`      // Min SOL a sell must return after fees
+	QuoteExpiresAt *time.Time     `json:"quoteExpiresAt"` // Reject the trade after this time
 }
 
 type CommentRequest struct {
@@ -165,8 +427,8 @@ type UpdateUserRequest struct {
 }
 
 type PortfolioItem struct {
-	Coin     *Coin   `json:"coin"`
-	Amount   float64 `json:"amount"`
-	Value    float64 `json:"value"`
-	AvgPrice float64 `json:"avgPrice"`
+	Coin     *Coin          `json:"coin"`
+	Amount   units.Tokens   `json:"amount"`
+	Value    units.Lamports `json:"value"`
+	AvgPrice units.Price    `json:"avgPrice"`
 }
@@ -0,0 +1,60 @@
+package sequencer
+
+import (
+	"errors"
+	"fmt"
+
+	"memepump/models"
+	"memepump/trading"
+
+	"gorm.io/gorm"
+)
+
+// Outcome is the result of one trade in a batch
+type Outcome struct {
+	Result *trading.Result
+	Err    error
+}
+
+// Committer applies trades in batches
+type Committer interface {
+	// Commit runs the trades in order inside one transaction. A trade that fails
+	// rolls back alone and reports its error in its Outcome; an error from Commit
+	// itself means nothing in the batch was stored.
+	Commit(reqs []*models.TradeRequest) ([]Outcome, error)
+}
+
+// DBCommitter commits batches to Postgres through trading.Execute
+type DBCommitter struct {
+	DB *gorm.DB
+}
+
+// Commit implements Committer. Each trade runs in a savepoint, so one rejected
+// trade does not undo the others, and the batch shares a single commit.
+func (c *DBCommitter) Commit(reqs []*models.TradeRequest) ([]Outcome, error) {
+	outcomes := make([]Outcome, len(reqs))
+	err := c.DB.Transaction(func(tx *gorm.DB) error {
+		for i, req := range reqs {
+			var result *trading.Result
+			err := tx.Transaction(func(trade *gorm.DB) error {
+				var err error
+				result, err = trading.Execute(trade, req)
+				return err
+			})
+			if err != nil && !isTradeError(err) {
+				return err
+			}
+			outcomes[i] = Outcome{Result: result, Err: err}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", trading.ErrStorage, err)
+	}
+	return outcomes, nil
+}
+
+// isTradeError reports whether err rejects the trade rather than failing the store
+func isTradeError(err error) bool {
+	return !errors.Is(err, trading.ErrStorage) && !errors.Is(err, trading.ErrInvalidCurve)
+}
@@ -0,0 +1,76 @@
+package sequencer
+
+import (
+	"context"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// Ownership decides which instance sequences a coin's trades
+type Ownership interface {
+	// Acquire claims the coin, reporting false if another instance owns it
+	Acquire(ctx context.Context, coinID string) (bool, error)
+	// Renew extends the claim, reporting false if it was lost
+	Renew(ctx context.Context, coinID string) (bool, error)
+	// Release gives the coin up
+	Release(ctx context.Context, coinID string) error
+}
+
+// LocalOwnership owns every coin, for a single instance
+type LocalOwnership struct{}
+
+func (LocalOwnership) Acquire(context.Context, string) (bool, error) { return true, nil }
+func (LocalOwnership) Renew(context.Context, string) (bool, error)   { return true, nil }
+func (LocalOwnership) Release(context.Context, string) error         { return nil }
+
+// RedisOwnership leases coins to instances through Redis keys that expire
+// unless the owner renews them
+type RedisOwnership struct {
+	Client     *redis.Client
+	InstanceID string
+	Lease      time.Duration
+}
+
+// Only the owner may renew or delete its lease
+var (
+	renewScript = redis.NewScript(`
+		if redis.call("GET", KEYS[1]) == ARGV[1] then
+			return redis.call("PEXPIRE", KEYS[1], ARGV[2])
+		end
+		return 0`)
+	releaseScript = redis.NewScript(`
+		if redis.call("GET", KEYS[1]) == ARGV[1] then
+			return redis.call("DEL", KEYS[1])
+		end
+		return 0`)
+)
+
+func ownerKey(coinID string) string {
+	return "sequencer:owner:" + coinID
+}
+
+// Acquire implements Ownership
+func (o *RedisOwnership) Acquire(ctx context.Context, coinID string) (bool, error) {
+	ok, err := o.Client.SetNX(ctx, ownerKey(coinID), o.InstanceID, o.Lease).Result()
+	if err != nil || ok {
+		return ok, err
+	}
+	// A restart may find its own lease still live
+	owner, err := o.Client.Get(ctx, ownerKey(coinID)).Result()
+	if err == redis.Nil {
+		return false, nil
+	}
+	return owner == o.InstanceID, err
+}
+
+// Renew implements Ownership
+func (o *RedisOwnership) Renew(ctx context.Context, coinID string) (bool, error) {
+	n, err := renewScript.Run(ctx, o.Client, []string{ownerKey(coinID)}, o.InstanceID, o.Lease.Milliseconds()).Int()
+	return n == 1, err
+}
+
+// Release implements Ownership
+func (o *RedisOwnership) Release(ctx context.Context, coinID string) error {
+	return releaseScript.Run(ctx, o.Client, []string{ownerKey(coinID)}, o.InstanceID).Err()
+}
@@ -0,0 +1,101 @@
+package sequencer
+
+import (
+	"os"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"memepump/blockchain"
+	"memepump/database"
+	"memepump/models"
+	"memepump/trading"
+	"memepump/units"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// The Postgres benchmarks trade against the database at TEST_DATABASE_URL,
+// migrated like the server's. Each run adds a coin and traders; trades are
+// append-only, so point it at a scratch database.
+//
+//	TEST_DATABASE_URL=postgres://... go test ./sequencer -run - -bench Postgres
+
+// benchTraders is how many users trade the hot coin
+const benchTraders = 16
+
+var connectOnce sync.Once
+
+func benchDB(b *testing.B) *gorm.DB {
+	dsn := os.Getenv("TEST_DATABASE_URL")
+	if dsn == "" {
+		b.Skip("TEST_DATABASE_URL not set")
+	}
+	connectOnce.Do(func() {
+		database.Connect(dsn)
+		database.DB.Logger = logger.Default.LogMode(logger.Silent)
+	})
+	return database.DB
+}
+
+// benchCoin launches a coin and funds benchTraders users with linked wallets,
+// returning their buys of one whole token in round-robin order
+func benchCoin(b *testing.B, db *gorm.DB) func() *models.TradeRequest {
+	coin := models.Coin{ID: uuid.New().String(), Name: "Bench", Symbol: "BENCH", CreatedAt: time.Now()}
+	trading.InitCoin(&coin, blockchain.DefaultCurve())
+	if err := db.Create(&coin).Error; err != nil {
+		b.Fatalf("create coin: %v", err)
+	}
+
+	reqs := make([]models.TradeRequest, benchTraders)
+	for i := range reqs {
+		userID := uuid.New().String()
+		wallet := "bench-" + userID
+		link := models.WalletLink{ID: uuid.New().String(), UserID: userID, Address: wallet, Chain: "solana", IsPrimary: true, CreatedAt: time.Now()}
+		err := db.Transaction(func(tx *gorm.DB) error {
+			if err := tx.Create(&link).Error; err != nil {
+				return err
+			}
+			deposit := models.SolTransaction{Kind: trading.SolTxDeposit, Amount: 1000000 * units.LamportsPerSol}
+			_, err := trading.PostSolTransaction(tx, userID, deposit)
+			return err
+		})
+		if err != nil {
+			b.Fatalf("fund trader: %v", err)
+		}
+		reqs[i] = models.TradeRequest{CoinID: coin.ID, Type: trading.SideBuy, Amount: units.TokenUnit, Wallet: wallet, UserID: userID}
+	}
+
+	var n int64
+	return func() *models.TradeRequest {
+		req := reqs[atomic.AddInt64(&n, 1)%benchTraders]
+		return &req
+	}
+}
+
+// BenchmarkPostgresDirectPath is the path with TRADE_SEQUENCER=off: one
+// transaction through trading.Execute per trade
+func BenchmarkPostgresDirectPath(b *testing.B) {
+	db := benchDB(b)
+	next := benchCoin(b, db)
+	benchmarkTrades(b, next, func(req *models.TradeRequest) error {
+		return db.Transaction(func(tx *gorm.DB) error {
+			_, err := trading.Execute(tx, req)
+			return err
+		})
+	})
+}
+
+// BenchmarkPostgresSequencer batches the same trades through DBCommitter
+func BenchmarkPostgresSequencer(b *testing.B) {
+	db := benchDB(b)
+	next := benchCoin(b, db)
+	s := New(&DBCommitter{DB: db}, LocalOwnership{})
+	benchmarkTrades(b, next, func(req *models.TradeRequest) error {
+		_, err := s.Submit(req)
+		return err
+	})
+}
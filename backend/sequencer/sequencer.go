@@ -0,0 +1,231 @@
+// Package sequencer applies each coin's trades through a single writer.
+//
+// Every active coin gets one actor goroutine on the instance that owns it. The
+// actor takes the trades queued for its coin in arrival order and commits them
+// in batches, so a hot launch pays for one transaction and one coin row lock per
+// batch instead of one per trade. Ownership is leased through Redis so each coin
+// has one sequencing instance; trades reaching any other instance, or arriving
+// while Redis is unavailable, fall back to a direct transaction. Correctness never
+// depends on ownership: trading.Execute still locks the coin row and hands out
+// the coin's trade sequence numbers under that lock.
+package sequencer
+
+import (
+	"context"
+	"errors"
+	"log"
+	"sync"
+	"time"
+
+	"memepump/models"
+	"memepump/trading"
+)
+
+// Sequencer defaults
+const (
+	DefaultMaxBatch      = 64
+	DefaultMaxQueue      = 4096
+	DefaultIdleTimeout   = 30 * time.Second
+	DefaultRenewInterval = 5 * time.Second
+	DefaultLease         = 15 * time.Second // Must outlast a few renew intervals
+)
+
+// ErrOverloaded rejects trades while a coin's queue is full
+var ErrOverloaded = errors.New("too many trades queued for this coin, retry shortly")
+
+type job struct {
+	req  *models.TradeRequest
+	done chan Outcome
+}
+
+// Sequencer routes trades to per-coin actors
+type Sequencer struct {
+	MaxBatch      int           // Trades per transaction
+	MaxQueue      int           // Trades waiting per coin before ErrOverloaded
+	IdleTimeout   time.Duration // An idle actor stops and releases its coin
+	RenewInterval time.Duration // How often actors renew ownership
+
+	committer Committer
+	owner     Ownership
+
+	mu     sync.Mutex // Guards actors and their queues
+	actors map[string]*actor
+}
+
+// New returns a sequencer committing through committer
+func New(committer Committer, owner Ownership) *Sequencer {
+	return &Sequencer{
+		MaxBatch:      DefaultMaxBatch,
+		MaxQueue:      DefaultMaxQueue,
+		IdleTimeout:   DefaultIdleTimeout,
+		RenewInterval: DefaultRenewInterval,
+		committer:     committer,
+		owner:         owner,
+		actors:        make(map[string]*actor),
+	}
+}
+
+// Submit applies a trade and returns once it is committed or rejected
+func (s *Sequencer) Submit(req *models.TradeRequest) (*trading.Result, error) {
+	j := &job{req: req, done: make(chan Outcome, 1)}
+	queued, err := s.enqueue(j)
+	if err != nil {
+		return nil, err
+	}
+	if !queued {
+		return s.direct(req)
+	}
+	out := <-j.done
+	return out.Result, out.Err
+}
+
+// direct commits a single trade outside any actor
+func (s *Sequencer) direct(req *models.TradeRequest) (*trading.Result, error) {
+	outcomes, err := s.committer.Commit([]*models.TradeRequest{req})
+	if err != nil {
+		return nil, err
+	}
+	return outcomes[0].Result, outcomes[0].Err
+}
+
+// enqueue hands the job to its coin's actor, starting one if this instance can
+// own the coin. It reports false when the trade should go direct instead.
+func (s *Sequencer) enqueue(j *job) (bool, error) {
+	coinID := j.req.CoinID
+
+	s.mu.Lock()
+	a, ok := s.actors[coinID]
+	if !ok {
+		s.mu.Unlock()
+		owned, err := s.owner.Acquire(context.Background(), coinID)
+		if err != nil {
+			log.Println("Sequencer ownership unavailable:", err)
+			return false, nil
+		}
+		if !owned {
+			return false, nil
+		}
+		s.mu.Lock()
+		if a, ok = s.actors[coinID]; !ok {
+			a = &actor{s: s, coinID: coinID, wake: make(chan struct{}, 1)}
+			s.actors[coinID] = a
+			go a.run()
+		}
+	}
+
+	if len(a.queue) >= s.MaxQueue {
+		s.mu.Unlock()
+		return false, ErrOverloaded
+	}
+	a.queue = append(a.queue, j)
+	s.mu.Unlock()
+
+	select {
+	case a.wake <- struct{}{}:
+	default: // Already signalled
+	}
+	return true, nil
+}
+
+// actor is the single writer for one coin
+type actor struct {
+	s      *Sequencer
+	coinID string
+	queue  []*job // Guarded by s.mu
+	wake   chan struct{}
+}
+
+func (a *actor) run() {
+	ticker := time.NewTicker(a.s.RenewInterval)
+	defer ticker.Stop()
+	lastActive := time.Now()
+
+	for {
+		if batch := a.take(); len(batch) > 0 {
+			a.commit(batch)
+			lastActive = time.Now()
+			continue
+		}
+
+		select {
+		case <-a.wake:
+		case <-ticker.C:
+			owned, err := a.s.owner.Renew(context.Background(), a.coinID)
+			if err == nil && !owned {
+				// Another instance took over; finish what was queued here and stop
+				a.commit(a.detach())
+				return
+			}
+			if time.Since(lastActive) >= a.s.IdleTimeout && a.stopIfIdle() {
+				return
+			}
+		}
+	}
+}
+
+// take removes the next batch from the queue
+func (a *actor) take() []*job {
+	a.s.mu.Lock()
+	defer a.s.mu.Unlock()
+	n := len(a.queue)
+	if n > a.s.MaxBatch {
+		n = a.s.MaxBatch
+	}
+	batch := a.queue[:n:n]
+	a.queue = a.queue[n:]
+	return batch
+}
+
+// detach removes the actor so new trades start over, returning what was still queued
+func (a *actor) detach() []*job {
+	a.s.mu.Lock()
+	defer a.s.mu.Unlock()
+	delete(a.s.actors, a.coinID)
+	rest := a.queue
+	a.queue = nil
+	return rest
+}
+
+// stopIfIdle releases the actor's coin and removes the actor unless trades arrived
+// meanwhile. It releases under the lock, before a new trade can start another
+// actor whose lease this release would drop.
+func (a *actor) stopIfIdle() bool {
+	a.s.mu.Lock()
+	defer a.s.mu.Unlock()
+	if len(a.queue) > 0 {
+		return false
+	}
+	if err := a.s.owner.Release(context.Background(), a.coinID); err != nil {
+		log.Println("Sequencer failed to release coin:", err)
+	}
+	delete(a.s.actors, a.coinID)
+	return true
+}
+
+// commit applies a batch in one transaction. If the transaction itself fails,
+// each trade is retried on its own so one bad trade cannot fail its neighbours.
+func (a *actor) commit(batch []*job) {
+	if len(batch) == 0 {
+		return
+	}
+	reqs := make([]*models.TradeRequest, len(batch))
+	for i, j := range batch {
+		reqs[i] = j.req
+	}
+
+	outcomes, err := a.s.committer.Commit(reqs)
+	if err != nil && len(batch) > 1 {
+		for _, j := range batch {
+			result, err := a.s.direct(j.req)
+			j.done <- Outcome{Result: result, Err: err}
+		}
+		return
+	}
+	for i, j := range batch {
+		if err != nil {
+			j.done <- Outcome{Err: err}
+			continue
+		}
+		j.done <- outcomes[i]
+	}
+}
@@ -0,0 +1,202 @@
+package sequencer
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"memepump/models"
+	"memepump/trading"
+)
+
+// commitLatency stands in for a Postgres round trip and fsync
+const commitLatency = 200 * time.Microsecond
+
+// fakeCommitter serialises commits per coin like the coin row lock does and
+// pays commitLatency once per transaction
+type fakeCommitter struct {
+	mu      sync.Mutex // The coin row lock, held until commit
+	seq     int64
+	batches int64
+}
+
+func (c *fakeCommitter) Commit(reqs []*models.TradeRequest) ([]Outcome, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	time.Sleep(commitLatency)
+	c.batches++
+
+	outcomes := make([]Outcome, len(reqs))
+	for i, req := range reqs {
+		if req.Type != trading.SideBuy {
+			outcomes[i] = Outcome{Err: trading.ErrInvalidSide}
+			continue
+		}
+		c.seq++
+		outcomes[i] = Outcome{Result: &trading.Result{Trade: models.Trade{CoinID: req.CoinID, Seq: c.seq}}}
+	}
+	return outcomes, nil
+}
+
+// foreignOwnership belongs to another instance
+type foreignOwnership struct{}
+
+func (foreignOwnership) Acquire(context.Context, string) (bool, error) { return false, nil }
+func (foreignOwnership) Renew(context.Context, string) (bool, error)   { return false, nil }
+func (foreignOwnership) Release(context.Context, string) error         { return nil }
+
+// leaseOwnership logs lease changes and holds the first release until unblocked
+type leaseOwnership struct {
+	mu        sync.Mutex
+	log       []string
+	releasing chan struct{}
+	unblock   chan struct{}
+}
+
+func (o *leaseOwnership) Acquire(context.Context, string) (bool, error) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.log = append(o.log, "acquire")
+	return true, nil
+}
+
+func (o *leaseOwnership) Renew(context.Context, string) (bool, error) { return true, nil }
+
+func (o *leaseOwnership) Release(context.Context, string) error {
+	select {
+	case o.releasing <- struct{}{}:
+	default:
+	}
+	<-o.unblock
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.log = append(o.log, "release")
+	return nil
+}
+
+func TestIdleStopReleasesBeforeANewActorAcquires(t *testing.T) {
+	owner := &leaseOwnership{releasing: make(chan struct{}, 1), unblock: make(chan struct{})}
+	s := New(&fakeCommitter{}, owner)
+	s.IdleTimeout, s.RenewInterval = time.Millisecond, time.Millisecond
+
+	if _, err := s.Submit(&models.TradeRequest{CoinID: "coin", Type: trading.SideBuy}); err != nil {
+		t.Fatalf("Submit: %v", err)
+	}
+
+	// A trade arriving while the idle actor releases must not start a new
+	// actor on the lease being released
+	<-owner.releasing
+	done := make(chan error, 1)
+	go func() {
+		_, err := s.Submit(&models.TradeRequest{CoinID: "coin", Type: trading.SideBuy})
+		done <- err
+	}()
+	time.Sleep(20 * time.Millisecond)
+	close(owner.unblock)
+	if err := <-done; err != nil {
+		t.Fatalf("Submit: %v", err)
+	}
+
+	owner.mu.Lock()
+	defer owner.mu.Unlock()
+	if len(owner.log) < 3 || owner.log[1] != "release" || owner.log[2] != "acquire" {
+		t.Errorf("lease changes %v; want the release before the second acquire", owner.log)
+	}
+}
+
+func TestSequencerAssignsUniqueSeqs(t *testing.T) {
+	committer := &fakeCommitter{}
+	s := New(committer, LocalOwnership{})
+
+	const trades = 500
+	seqs := make(chan int64, trades)
+	var wg sync.WaitGroup
+	for i := 0; i < trades; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			result, err := s.Submit(&models.TradeRequest{CoinID: "coin", Type: trading.SideBuy})
+			if err != nil {
+				t.Errorf("Submit: %v", err)
+				return
+			}
+			seqs <- result.Trade.Seq
+		}()
+	}
+	wg.Wait()
+	close(seqs)
+
+	seen := make(map[int64]bool)
+	for seq := range seqs {
+		if seq < 1 || seq > trades || seen[seq] {
+			t.Fatalf("seq %d duplicated or out of range", seq)
+		}
+		seen[seq] = true
+	}
+	if committer.batches >= trades {
+		t.Errorf("%d trades took %d commits; want batching", trades, committer.batches)
+	}
+
+	// A rejected trade fails alone
+	if _, err := s.Submit(&models.TradeRequest{CoinID: "coin", Type: "hold"}); !errors.Is(err, trading.ErrInvalidSide) {
+		t.Errorf("invalid trade error = %v; want ErrInvalidSide", err)
+	}
+}
+
+func TestSequencerFallsBackWithoutOwnership(t *testing.T) {
+	committer := &fakeCommitter{}
+	s := New(committer, foreignOwnership{})
+
+	result, err := s.Submit(&models.TradeRequest{CoinID: "coin", Type: trading.SideBuy})
+	if err != nil || result.Trade.Seq != 1 {
+		t.Fatalf("Submit = %+v, %v; want a direct commit", result, err)
+	}
+	if len(s.actors) != 0 {
+		t.Error("started an actor for a coin owned elsewhere")
+	}
+}
+
+// benchmarkTrades submits b.N trades made by next from many goroutines
+func benchmarkTrades(b *testing.B, next func() *models.TradeRequest, submit func(*models.TradeRequest) error) {
+	var done int64
+	b.SetParallelism(16)
+	b.ResetTimer()
+	start := time.Now()
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			if err := submit(next()); err != nil {
+				b.Error(err)
+				return
+			}
+			atomic.AddInt64(&done, 1)
+		}
+	})
+	b.ReportMetric(float64(done)/time.Since(start).Seconds(), "trades/s")
+}
+
+// fakeTrade is a buy on the hot coin of the fake benchmarks
+func fakeTrade() *models.TradeRequest {
+	return &models.TradeRequest{CoinID: "coin", Type: trading.SideBuy}
+}
+
+// BenchmarkDirectPath models one transaction per trade with fakeCommitter's fixed
+// commit latency. It shows what batching saves, not real throughput; see
+// BenchmarkPostgresDirectPath.
+func BenchmarkDirectPath(b *testing.B) {
+	committer := &fakeCommitter{}
+	benchmarkTrades(b, fakeTrade, func(req *models.TradeRequest) error {
+		_, err := committer.Commit([]*models.TradeRequest{req})
+		return err
+	})
+}
+
+func BenchmarkSequencer(b *testing.B) {
+	s := New(&fakeCommitter{}, LocalOwnership{})
+	benchmarkTrades(b, fakeTrade, func(req *models.TradeRequest) error {
+		_, err := s.Submit(req)
+		return err
+	})
+}
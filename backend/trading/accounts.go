@@ -0,0 +1,105 @@
+package trading
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"memepump/models"
+	"memepump/units"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// SOL account transaction kinds
+const (
+	SolTxDeposit    = "deposit"
+	SolTxWithdrawal = "withdrawal"
+	SolTxBuy        = "buy"
+	SolTxSell       = "sell"
+	SolTxFeeClaim   = "fee_claim"
+	SolTxLPAdd      = "lp_add"
+	SolTxLPRemove   = "lp_remove"
+	SolTxReserve    = "order_reserve"
+	SolTxRelease    = "order_release"
+)
+
+// SOL account transaction statuses
+const (
+	SolTxCompleted = "completed"
+	SolTxPending   = "pending"
+)
+
+// Account errors
+var (
+	ErrNoAccount         = errors.New("trading requires a user account")
+	ErrInsufficientFunds = errors.New("insufficient SOL balance")
+)
+
+// lockAccount loads the user's SOL account for update, opening an empty one if needed
+func lockAccount(tx *gorm.DB, userID string) (*models.SolAccount, error) {
+	if userID == "" {
+		return nil, ErrNoAccount
+	}
+	account := models.SolAccount{UserID: userID, UpdatedAt: time.Now()}
+	if err := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&account).Error; err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrStorage, err)
+	}
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&account, "user_id = ?", userID).Error; err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrStorage, err)
+	}
+	return &account, nil
+}
+
+// PostSolTransaction applies entry.Amount to the user's SOL account and records it.
+// Debits that would overdraw the account fail with ErrInsufficientFunds.
+// The caller owns tx.
+func PostSolTransaction(tx *gorm.DB, userID string, entry models.SolTransaction) (*models.SolTransaction, error) {
+	account, err := lockAccount(tx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if account.Balance+entry.Amount < 0 {
+		return nil, ErrInsufficientFunds
+	}
+
+	account.Balance += entry.Amount
+	account.UpdatedAt = time.Now()
+	if err := tx.Save(account).Error; err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrStorage, err)
+	}
+
+	entry.ID = uuid.New().String()
+	entry.UserID = userID
+	entry.BalanceAfter = account.Balance
+	if entry.Status == "" {
+		entry.Status = SolTxCompleted
+	}
+	entry.CreatedAt = account.UpdatedAt
+	if err := tx.Create(&entry).Error; err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrStorage, err)
+	}
+	return &entry, nil
+}
+
+// settleFunds debits a buy's total from the trader's account or credits a sell's
+func settleFunds(tx *gorm.DB, userID, tradeID string, fill *Fill) error {
+	entry := models.SolTransaction{Kind: SolTxBuy, Amount: -fill.Total, TradeID: tradeID}
+	if fill.Side == SideSell {
+		entry = models.SolTransaction{Kind: SolTxSell, Amount: fill.Total, TradeID: tradeID}
+	}
+	_, err := PostSolTransaction(tx, userID, entry)
+	return err
+}
+
+// SolBalance returns the user's SOL balance, zero if no account was opened yet
+func SolBalance(db *gorm.DB, userID string) (units.Lamports, error) {
+	var account models.SolAccount
+	err := db.First(&account, "user_id = ?", userID).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return 0, nil
+	}
+	return account.Balance, err
+}
@@ -0,0 +1,95 @@
+package trading
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"memepump/models"
+	"memepump/units"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Balance errors
+var (
+	ErrInsufficientBalance = errors.New("insufficient token balance")
+	ErrWalletNotOwned      = errors.New("wallet belongs to another user")
+	ErrWalletNotLinked     = errors.New("wallet is not linked to your account")
+)
+
+// checkWalletLinked rejects wallets the user has not linked to their account.
+// Balances are keyed by wallet, so trading one must prove control of it first.
+func checkWalletLinked(tx *gorm.DB, userID, wallet string) error {
+	var count int64
+	err := tx.Model(&models.WalletLink{}).Where("address = ? AND user_id = ?", wallet, userID).Count(&count).Error
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrStorage, err)
+	}
+	if count == 0 {
+		return ErrWalletNotLinked
+	}
+	return nil
+}
+
+// lockBalance loads the wallet's balance row for update, or a zero balance if it has none
+func lockBalance(tx *gorm.DB, wallet, coinID string) (*models.Balance, error) {
+	var balance models.Balance
+	err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		First(&balance, "wallet = ? AND coin_id = ?", wallet, coinID).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return &models.Balance{Wallet: wallet, CoinID: coinID}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrStorage, err)
+	}
+	return &balance, nil
+}
+
+// checkBalance rejects trades on another user's wallet and sells beyond the unreserved balance
+func checkBalance(balance *models.Balance, req *models.TradeRequest) error {
+	if balance.UserID != "" && req.UserID != "" && balance.UserID != req.UserID {
+		return ErrWalletNotOwned
+	}
+	if req.Type == SideSell && req.Amount > balance.Amount-balance.Reserved {
+		return ErrInsufficientBalance
+	}
+	return nil
+}
+
+// applyFill moves a fill into the balance and reports whether the wallet
+// started or stopped holding the coin (+1, -1 or 0)
+func applyFill(balance *models.Balance, fill *Fill) (holderDelta int) {
+	wasHolder := balance.Amount > 0
+	switch fill.Side {
+	case SideBuy:
+		balance.Amount += fill.Amount
+		balance.CostBasis += fill.Total
+	case SideSell:
+		// Release cost basis in proportion to the tokens sold
+		released := units.MulDiv(int64(balance.CostBasis), int64(fill.Amount), int64(balance.Amount))
+		balance.Amount -= fill.Amount
+		balance.CostBasis -= units.Lamports(released)
+	}
+	isHolder := balance.Amount > 0
+	if isHolder && !wasHolder {
+		return 1
+	}
+	if wasHolder && !isHolder {
+		return -1
+	}
+	return 0
+}
+
+// saveBalance stores the balance, assigning it to the user the wallet is linked to
+func saveBalance(tx *gorm.DB, balance *models.Balance, userID string) error {
+	if balance.UserID == "" {
+		balance.UserID = userID
+	}
+	balance.UpdatedAt = time.Now()
+	if err := tx.Save(balance).Error; err != nil {
+		return fmt.Errorf("%w: %v", ErrStorage, err)
+	}
+	return nil
+}
@@ -0,0 +1,165 @@
+package trading
+
+import (
+	"fmt"
+	"time"
+
+	"memepump/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// CandleInterval is a supported candle length
+type CandleInterval struct {
+	Name     string
+	Duration time.Duration
+}
+
+// CandleIntervals are the intervals every trade updates, shortest first
+var CandleIntervals = []CandleInterval{
+	{"1s", time.Second},
+	{"1m", time.Minute},
+	{"5m", 5 * time.Minute},
+	{"15m", 15 * time.Minute},
+	{"1h", time.Hour},
+	{"4h", 4 * time.Hour},
+	{"1d", 24 * time.Hour},
+}
+
+// LookupCandleInterval returns the interval with the given name
+func LookupCandleInterval(name string) (CandleInterval, bool) {
+	for _, interval := range CandleIntervals {
+		if interval.Name == name {
+			return interval, true
+		}
+	}
+	return CandleInterval{}, false
+}
+
+// CandleStart returns the UTC start of the interval containing t. Intervals are
+// aligned to the Unix epoch, like date_bin in the backfill.
+func CandleStart(t time.Time, d time.Duration) time.Time {
+	return t.UTC().Truncate(d)
+}
+
+// tradeCandle is the candle of a single trade. Trades stored before spot prices
+// were recorded fall back to their average fill price.
+func tradeCandle(trade *models.Trade, interval CandleInterval) models.Candle {
+	open, close := trade.PriceBefore, trade.PriceAfter
+	if open == 0 || close == 0 {
+		open, close = trade.Price, trade.Price
+	}
+	high, low := open, close
+	if close > open {
+		high, low = close, open
+	}
+	return models.Candle{
+		CoinID:      trade.CoinID,
+		Interval:    interval.Name,
+		Start:       CandleStart(trade.Timestamp, interval.Duration),
+		Open:        open,
+		High:        high,
+		Low:         low,
+		Close:       close,
+		Volume:      trade.SolAmount,
+		TokenVolume: trade.Amount,
+		Trades:      1,
+	}
+}
+
+// recordCandles folds a trade into its coin's candles of every interval in one
+// upsert. The coin row is locked, so trades arrive in order and the last one
+// sets the close.
+func recordCandles(tx *gorm.DB, trade *models.Trade) error {
+	candles := make([]models.Candle, len(CandleIntervals))
+	for i, interval := range CandleIntervals {
+		candles[i] = tradeCandle(trade, interval)
+	}
+	err := tx.Clauses(clause.OnConflict{
+		Columns: []clause.Column{{Name: "coin_id"}, {Name: "period"}, {Name: "start"}},
+		DoUpdates: clause.Assignments(map[string]interface{}{
+			"high":         gorm.Expr("GREATEST(candles.high, excluded.high)"),
+			"low":          gorm.Expr("LEAST(candles.low, excluded.low)"),
+			"close":        gorm.Expr("excluded.close"),
+			"volume":       gorm.Expr("candles.volume + excluded.volume"),
+			"token_volume": gorm.Expr("candles.token_volume + excluded.token_volume"),
+			"trades":       gorm.Expr("candles.trades + 1"),
+		}),
+	}).Create(&candles).Error
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrStorage, err)
+	}
+	return nil
+}
+
+// RebuildCandles recomputes candles from the trade history, for one coin or all
+// of them when coinID is empty. Trades wait on the table lock until tx commits, so
+// none is counted twice or lost. The caller owns tx.
+func RebuildCandles(tx *gorm.DB, coinID string) error {
+	if err := tx.Exec("LOCK TABLE candles IN SHARE ROW EXCLUSIVE MODE").Error; err != nil {
+		return fmt.Errorf("%w: %v", ErrStorage, err)
+	}
+	del := tx.Where("1 = 1")
+	if coinID != "" {
+		del = tx.Where("coin_id = ?", coinID)
+	}
+	if err := del.Delete(&models.Candle{}).Error; err != nil {
+		return fmt.Errorf("%w: %v", ErrStorage, err)
+	}
+
+	for _, interval := range CandleIntervals {
+		err := tx.Exec(`
+			INSERT INTO candles (coin_id, period, start, open, high, low, close, volume, token_volume, trades)
+			SELECT coin_id, ?, bucket,
+				(ARRAY_AGG(open ORDER BY seq))[1], MAX(GREATEST(open, close)), MIN(LEAST(open, close)),
+				(ARRAY_AGG(close ORDER BY seq DESC))[1], SUM(sol_amount), SUM(amount), COUNT(*)
+			FROM (
+				SELECT coin_id, seq, sol_amount, amount,
+					DATE_BIN(?::interval, timestamp, TIMESTAMPTZ '1970-01-01 00:00:00+00') AS bucket,
+					CASE WHEN price_before > 0 AND price_after > 0 THEN price_before ELSE price END AS open,
+					CASE WHEN price_before > 0 AND price_after > 0 THEN price_after ELSE price END AS close
+				FROM trades
+				WHERE ? = '' OR coin_id = ?
+			) AS priced
+			GROUP BY coin_id, bucket
+		`, interval.Name, fmt.Sprintf("%d seconds", int64(interval.Duration/time.Second)), coinID, coinID).Error
+		if err != nil {
+			return fmt.Errorf("%w: %v", ErrStorage, err)
+		}
+	}
+	return nil
+}
+
+// BackfillCandles builds candles from the trade history the first time the table is empty
+func BackfillCandles(db *gorm.DB) error {
+	var count int64
+	if err := db.Model(&models.Candle{}).Count(&count).Error; err != nil || count > 0 {
+		return err
+	}
+	return db.Transaction(func(tx *gorm.DB) error {
+		return RebuildCandles(tx, "")
+	})
+}
+
+// FillCandleGaps carries the close across intervals without trades, so a chart
+// shows the flat price the coin stood at. Candles must be in start order.
+func FillCandleGaps(candles []models.Candle, d time.Duration) []models.Candle {
+	if len(candles) == 0 {
+		return candles
+	}
+	filled := make([]models.Candle, 0, len(candles))
+	for i, candle := range candles {
+		if i > 0 {
+			prev := filled[len(filled)-1]
+			for start := prev.Start.Add(d); start.Before(candle.Start); start = start.Add(d) {
+				filled = append(filled, models.Candle{
+					CoinID: prev.CoinID, Interval: prev.Interval, Start: start,
+					Open: prev.Close, High: prev.Close, Low: prev.Close, Close: prev.Close,
+				})
+			}
+		}
+		filled = append(filled, candle)
+	}
+	return filled
+}
@@ -0,0 +1,66 @@
+package trading
+
+import (
+	"testing"
+	"time"
+
+	"memepump/models"
+	"memepump/units"
+)
+
+func TestCandleStartAlignsToUTC(t *testing.T) {
+	berlin := time.FixedZone("CEST", 2*60*60)
+	at := time.Date(2026, 3, 14, 5, 47, 31, 500, berlin) // 03:47:31 UTC
+
+	tests := map[string]time.Time{
+		"1s":  time.Date(2026, 3, 14, 3, 47, 31, 0, time.UTC),
+		"15m": time.Date(2026, 3, 14, 3, 45, 0, 0, time.UTC),
+		"4h":  time.Date(2026, 3, 14, 0, 0, 0, 0, time.UTC),
+		"1d":  time.Date(2026, 3, 14, 0, 0, 0, 0, time.UTC),
+	}
+	for name, want := range tests {
+		interval, ok := LookupCandleInterval(name)
+		if !ok {
+			t.Fatalf("interval %s missing", name)
+		}
+		if got := CandleStart(at, interval.Duration); !got.Equal(want) {
+			t.Errorf("%s candle starts at %s; want %s", name, got, want)
+		}
+	}
+}
+
+func TestTradeCandle(t *testing.T) {
+	interval, _ := LookupCandleInterval("1m")
+	trade := models.Trade{
+		Timestamp: time.Now(), Price: 150, PriceBefore: 200, PriceAfter: 100,
+		SolAmount: units.LamportsPerSol, Amount: units.TokenUnit,
+	}
+	candle := tradeCandle(&trade, interval)
+	if candle.Open != 200 || candle.High != 200 || candle.Low != 100 || candle.Close != 100 {
+		t.Errorf("sell candle = %+v; want open/high 200, low/close 100", candle)
+	}
+
+	// Trades from before spot prices were stored use their fill price
+	trade.PriceBefore, trade.PriceAfter = 0, 0
+	candle = tradeCandle(&trade, interval)
+	if candle.Open != 150 || candle.High != 150 || candle.Low != 150 || candle.Close != 150 {
+		t.Errorf("legacy candle = %+v; want 150 throughout", candle)
+	}
+}
+
+func TestFillCandleGaps(t *testing.T) {
+	start := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	candles := []models.Candle{
+		{Start: start, Open: 1, High: 3, Low: 1, Close: 2, Trades: 2},
+		{Start: start.Add(3 * time.Minute), Open: 2, High: 4, Low: 2, Close: 4, Trades: 1},
+	}
+	filled := FillCandleGaps(candles, time.Minute)
+	if len(filled) != 4 {
+		t.Fatalf("filled %d candles; want 4", len(filled))
+	}
+	for _, gap := range filled[1:3] {
+		if gap.Open != 2 || gap.Close != 2 || gap.Trades != 0 {
+			t.Errorf("gap candle = %+v; want flat at the previous close", gap)
+		}
+	}
+}
@@ -0,0 +1,68 @@
+package trading
+
+import (
+	"encoding/json"
+
+	"memepump/blockchain"
+	"memepump/models"
+)
+
+// CurveForCoin builds the bonding curve stored on a coin
+func CurveForCoin(coin *models.Coin) (*blockchain.BondingCurve, error) {
+	spec := blockchain.CurveSpec{
+		Type:      blockchain.CurveType(coin.CurveType),
+		BasePrice: coin.BasePrice,
+		K:         coin.CurveK,
+		Slope:     coin.CurveSlope,
+		MaxSupply: coin.MaxSupply,
+	}
+	if coin.CurveParams != "" {
+		spec.Params = json.RawMessage(coin.CurveParams)
+	}
+	return blockchain.NewFromParams(spec, coin.TargetMcap)
+}
+
+// NewCurve validates creation parameters; nil params select the default curve
+func NewCurve(params *models.CurveParams) (*blockchain.BondingCurve, error) {
+	if params == nil {
+		params = &models.CurveParams{}
+	}
+	return blockchain.NewFromParams(blockchain.CurveSpec{
+		Type:      blockchain.CurveType(params.Type),
+		BasePrice: params.BasePrice,
+		K:         params.K,
+		Slope:     params.Slope,
+		Params:    params.Params,
+		MaxSupply: params.MaxSupply,
+	}, params.TargetMcap)
+}
+
+// InitCoin stores the curve on a fresh coin and sets its launch state
+func InitCoin(coin *models.Coin, curve *blockchain.BondingCurve) {
+	spec := curve.Curve.Spec()
+	coin.CurveType = string(spec.Type)
+	coin.CurveK = spec.K
+	coin.CurveSlope = spec.Slope
+	coin.CurveParams = string(spec.Params)
+	coin.BasePrice = spec.BasePrice
+	coin.MaxSupply = curve.MaxSupply
+	coin.TargetMcap = curve.TargetMcap
+
+	coin.TotalSupply = 0
+	coin.RealSolReserves = 0
+	refreshCoin(coin, curve)
+}
+
+// refreshCoin recomputes price, market cap, progress and reserves from the current supply
+func refreshCoin(coin *models.Coin, curve *blockchain.BondingCurve) {
+	coin.Price = curve.CalculatePrice(coin.TotalSupply)
+	coin.MarketCap = curve.CalculateMarketCap(coin.TotalSupply)
+	coin.Progress = curve.CalculateProgress(coin.TotalSupply, coin.MarketCap)
+	coin.RealTokenReserves = curve.MaxSupply - coin.TotalSupply
+	coin.VirtualSolReserves, coin.VirtualTokenReserves, _ = curve.VirtualReserves(coin.TotalSupply)
+}
+
+// ShouldGraduate reports whether the coin has reached its curve target
+func ShouldGraduate(coin *models.Coin, curve *blockchain.BondingCurve) bool {
+	return curve.ShouldGraduate(coin.TotalSupply, coin.MarketCap)
+}
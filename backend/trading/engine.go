@@ -0,0 +1,219 @@
+package trading
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"memepump/blockchain"
+	"memepump/models"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Execution errors
+var (
+	ErrCoinNotFound    = errors.New("coin not found")
+	ErrInvalidCurve    = errors.New("invalid coin curve")
+	ErrAmountAndSol    = errors.New("provide either amount or solAmount")
+	ErrSolAmountOnSell = errors.New("solAmount is only supported for buys")
+	ErrQuoteExpired    = errors.New("quote expired")
+	ErrStorage         = errors.New("failed to store trade")
+)
+
+// SlippageError reports that the locked fill fell outside the trader's bounds
+type SlippageError struct {
+	Bound string `json:"bound"` // "maxCost", "minTokensOut" or "minReturn"
+	Limit int64  `json:"limit,string"`
+	Fill  *Fill  `json:"fill"` // What the trade would have executed at
+}
+
+func (e *SlippageError) Error() string {
+	return fmt.Sprintf("slippage exceeded: %s %d, would fill %d token units for %d lamports",
+		e.Bound, e.Limit, e.Fill.Amount, e.Fill.Total)
+}
+
+// Result is a trade settled inside the caller's transaction
+type Result struct {
+	Trade     models.Trade         `json:"trade"`
+	Coin      models.Coin          `json:"coin"`
+	Fill      *Fill                `json:"fill"`
+	Migration *models.MigrationJob `json:"migration,omitempty"` // Set when this trade graduated the coin
+}
+
+// Execute locks the coin and balance rows, settles the trade on the coin's curve
+// (or its pool once graduated), moves SOL through the trader's account and stores
+// the trade, balance and fees. The caller owns tx and must commit or roll back.
+func Execute(tx *gorm.DB, req *models.TradeRequest) (*Result, error) {
+	if err := validateRequest(req, time.Now()); err != nil {
+		return nil, err
+	}
+	if req.UserID == "" {
+		return nil, ErrNoAccount
+	}
+
+	var coin models.Coin
+	// Lock row for update
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&coin, "id = ?", req.CoinID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrCoinNotFound
+		}
+		return nil, fmt.Errorf("%w: %v", ErrStorage, err)
+	}
+
+	// Graduated coins trade on their pool, the rest on their curve
+	var curve *blockchain.BondingCurve
+	var pool *models.Pool
+	var err error
+	if coin.Graduated {
+		if pool, err = lockPool(tx, coin.ID); err != nil {
+			return nil, err
+		}
+	} else if curve, err = CurveForCoin(&coin); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidCurve, err)
+	}
+
+	if err := checkWalletLinked(tx, req.UserID, req.Wallet); err != nil {
+		return nil, err
+	}
+	balance, err := lockBalance(tx, req.Wallet, coin.ID)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkBalance(balance, req); err != nil {
+		return nil, err
+	}
+
+	var fill *Fill
+	amount := req.Amount
+	if pool != nil {
+		if req.SolAmount > 0 {
+			amount = PoolTokensForBudget(pool, req.SolAmount, Fees)
+		}
+		fill, err = Swap(&coin, pool, req.Type, amount)
+	} else {
+		if req.SolAmount > 0 {
+			amount = TokensForBudget(&coin, curve, req.SolAmount, Fees)
+		}
+		fill, err = Settle(&coin, curve, req.Type, amount)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if err := CheckSlippage(req, fill); err != nil {
+		return nil, err
+	}
+	if err := checkLaunchRules(tx, &coin, req, fill, time.Now()); err != nil {
+		return nil, err
+	}
+	if err := checkHoldingCap(tx, &coin, req, fill); err != nil {
+		return nil, err
+	}
+
+	trade := models.Trade{
+		ID:          uuid.New().String(),
+		CoinID:      coin.ID,
+		Type:        req.Type,
+		Venue:       VenueCurve,
+		Amount:      fill.Amount,
+		SolAmount:   fill.SolAmount,
+		PlatformFee: fill.PlatformFee,
+		CreatorFee:  fill.CreatorFee,
+		GasFee:      fill.PlatformFee + fill.CreatorFee,
+		Price:       fill.AvgPrice,
+		PriceBefore: fill.PriceBefore,
+		PriceAfter:  fill.PriceAfter,
+		Wallet:      req.Wallet,
+		UserID:      req.UserID,
+		Username:    req.Username,
+		Timestamp:   time.Now(),
+	}
+
+	if pool != nil {
+		trade.Venue = VenuePool
+	}
+
+	// The coin row is locked, so sequence numbers are handed out in commit order
+	coin.TradeSeq++
+	trade.Seq = coin.TradeSeq
+
+	// Pay for the buy or collect the sell proceeds
+	if err := settleFunds(tx, req.UserID, trade.ID, fill); err != nil {
+		return nil, err
+	}
+
+	coin.Holders += applyFill(balance, fill)
+	if err := saveBalance(tx, balance, req.UserID); err != nil {
+		return nil, err
+	}
+
+	// Crossing the target closes the curve in this same transaction
+	var migration *models.MigrationJob
+	if curve != nil && ShouldGraduate(&coin, curve) {
+		if migration, err = graduate(tx, &coin, trade.Timestamp); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := tx.Save(&coin).Error; err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrStorage, err)
+	}
+	if pool != nil {
+		pool.UpdatedAt = trade.Timestamp
+		if err := tx.Save(pool).Error; err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrStorage, err)
+		}
+	}
+	if err := tx.Create(&trade).Error; err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrStorage, err)
+	}
+	if err := recordFees(tx, &coin, &trade); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrStorage, err)
+	}
+	if err := recordCandles(tx, &trade); err != nil {
+		return nil, err
+	}
+
+	return &Result{Trade: trade, Coin: coin, Fill: fill, Migration: migration}, nil
+}
+
+// validateRequest rejects malformed or stale requests before any row is locked
+func validateRequest(req *models.TradeRequest, now time.Time) error {
+	if req.Type != SideBuy && req.Type != SideSell {
+		return ErrInvalidSide
+	}
+	if req.Amount < 0 || req.SolAmount < 0 || (req.Amount == 0 && req.SolAmount == 0) {
+		return ErrInvalidAmount
+	}
+	if req.Amount > 0 && req.SolAmount > 0 {
+		return ErrAmountAndSol
+	}
+	if req.SolAmount > 0 && req.Type != SideBuy {
+		return ErrSolAmountOnSell
+	}
+	if req.QuoteExpiresAt != nil && now.After(*req.QuoteExpiresAt) {
+		return ErrQuoteExpired
+	}
+	return nil
+}
+
+// CheckSlippage compares a fill with the optional bounds on the request
+func CheckSlippage(req *models.TradeRequest, fill *Fill) error {
+	switch fill.Side {
+	case SideBuy:
+		if req.MaxCost > 0 && fill.Total > req.MaxCost {
+			return &SlippageError{Bound: "maxCost", Limit: int64(req.MaxCost), Fill: fill}
+		}
+		if req.MinTokensOut > 0 && fill.Amount < req.MinTokensOut {
+			return &SlippageError{Bound: "minTokensOut", Limit: int64(req.MinTokensOut), Fill: fill}
+		}
+	case SideSell:
+		if req.MinReturn > 0 && fill.Total < req.MinReturn {
+			return &SlippageError{Bound: "minReturn", Limit: int64(req.MinReturn), Fill: fill}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,112 @@
+package trading
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"memepump/models"
+)
+
+func TestCheckSlippage(t *testing.T) {
+	// Bounds apply to what the trader pays or receives, fees included
+	buy := &Fill{Side: SideBuy, Amount: 1000, SolAmount: 1980, Total: 2000}
+	sell := &Fill{Side: SideSell, Amount: 1000, SolAmount: 2020, Total: 2000}
+
+	tests := []struct {
+		req   models.TradeRequest
+		fill  *Fill
+		bound string
+	}{
+		{models.TradeRequest{MaxCost: 2000}, buy, ""},
+		{models.TradeRequest{MaxCost: 1999}, buy, "maxCost"},
+		{models.TradeRequest{MinTokensOut: 1001}, buy, "minTokensOut"},
+		{models.TradeRequest{MinReturn: 2000}, sell, ""},
+		{models.TradeRequest{MinReturn: 2001}, sell, "minReturn"},
+		{models.TradeRequest{MaxCost: 1}, sell, ""}, // Buy bounds ignored on sells
+	}
+
+	for _, test := range tests {
+		err := CheckSlippage(&test.req, test.fill)
+		var slippage *SlippageError
+		switch {
+		case test.bound == "" && err != nil:
+			t.Errorf("CheckSlippage(%+v) = %v; want nil", test.req, err)
+		case test.bound != "" && (!errors.As(err, &slippage) || slippage.Bound != test.bound):
+			t.Errorf("CheckSlippage(%+v) = %v; want %s violation", test.req, err, test.bound)
+		}
+	}
+}
+
+func TestValidateRequest(t *testing.T) {
+	now := time.Now()
+	past := now.Add(-time.Second)
+
+	tests := []struct {
+		req models.TradeRequest
+		err error
+	}{
+		{models.TradeRequest{Type: SideBuy, Amount: 1}, nil},
+		{models.TradeRequest{Type: SideBuy, SolAmount: 1}, nil},
+		{models.TradeRequest{Type: SideBuy}, ErrInvalidAmount},
+		{models.TradeRequest{Type: SideBuy, Amount: 1, SolAmount: 1}, ErrAmountAndSol},
+		{models.TradeRequest{Type: SideSell, SolAmount: 1}, ErrSolAmountOnSell},
+		{models.TradeRequest{Type: SideBuy, Amount: 1, QuoteExpiresAt: &past}, ErrQuoteExpired},
+	}
+
+	for _, test := range tests {
+		if err := validateRequest(&test.req, now); err != test.err {
+			t.Errorf("validateRequest(%+v) = %v; want %v", test.req, err, test.err)
+		}
+	}
+}
+
+func TestCheckBalance(t *testing.T) {
+	balance := &models.Balance{Wallet: "w1", CoinID: "c1", UserID: "alice", Amount: 1000}
+
+	tests := []struct {
+		req  models.TradeRequest
+		want error
+	}{
+		{models.TradeRequest{Type: SideSell, Amount: 1000, UserID: "alice"}, nil},
+		{models.TradeRequest{Type: SideSell, Amount: 1001, UserID: "alice"}, ErrInsufficientBalance},
+		{models.TradeRequest{Type: SideBuy, Amount: 5000, UserID: "alice"}, nil},
+		{models.TradeRequest{Type: SideSell, Amount: 1, UserID: "mallory"}, ErrWalletNotOwned},
+		{models.TradeRequest{Type: SideBuy, Amount: 1, UserID: "mallory"}, ErrWalletNotOwned},
+	}
+	for _, test := range tests {
+		if err := checkBalance(balance, &test.req); err != test.want {
+			t.Errorf("checkBalance(%+v) = %v; want %v", test.req, err, test.want)
+		}
+	}
+
+	if err := checkBalance(&models.Balance{}, &models.TradeRequest{Type: SideSell, Amount: 1}); err != ErrInsufficientBalance {
+		t.Errorf("selling from an empty wallet = %v; want %v", err, ErrInsufficientBalance)
+	}
+
+	// Tokens reserved for open sell orders cannot be sold again
+	reserved := &models.Balance{UserID: "alice", Amount: 1000, Reserved: 600}
+	if err := checkBalance(reserved, &models.TradeRequest{Type: SideSell, Amount: 401, UserID: "alice"}); err != ErrInsufficientBalance {
+		t.Errorf("selling reserved tokens = %v; want %v", err, ErrInsufficientBalance)
+	}
+}
+
+func TestApplyFillTracksHoldersAndCostBasis(t *testing.T) {
+	var balance models.Balance
+
+	if delta := applyFill(&balance, &Fill{Side: SideBuy, Amount: 300, Total: 900}); delta != 1 {
+		t.Errorf("first buy holder delta = %d; want 1", delta)
+	}
+	if delta := applyFill(&balance, &Fill{Side: SideSell, Amount: 100, Total: 500}); delta != 0 {
+		t.Errorf("partial sell holder delta = %d; want 0", delta)
+	}
+	if balance.Amount != 200 || balance.CostBasis != 600 {
+		t.Errorf("after partial sell = %d tokens, %d basis; want 200, 600", balance.Amount, balance.CostBasis)
+	}
+	if delta := applyFill(&balance, &Fill{Side: SideSell, Amount: 200, Total: 100}); delta != -1 {
+		t.Errorf("closing sell holder delta = %d; want -1", delta)
+	}
+	if balance.Amount != 0 || balance.CostBasis != 0 {
+		t.Errorf("after closing sell = %d tokens, %d basis; want 0, 0", balance.Amount, balance.CostBasis)
+	}
+}
@@ -0,0 +1,202 @@
+package trading
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"memepump/blockchain"
+	"memepump/models"
+	"memepump/units"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Fee ledger entry kinds
+const (
+	FeeKindPlatform = "platform" // Platform fee taken from a trade
+	FeeKindCreator  = "creator"  // Creator fee accrued from a trade
+	FeeKindClaim    = "claim"    // Creator payout, stored as a negative amount
+)
+
+// BpsDenominator is 100% in basis points
+const BpsDenominator = 10000
+
+// MaxFeeBps caps the combined platform and creator fee at 10%
+const MaxFeeBps = 1000
+
+// Default fees, overridable through PLATFORM_FEE_BPS and CREATOR_FEE_BPS
+const (
+	DefaultPlatformFeeBps = 100 // 1%
+	DefaultCreatorFeeBps  = 50  // 0.5%
+)
+
+// ErrNothingToClaim is returned when a creator has no accrued fees
+var ErrNothingToClaim = errors.New("no creator fees to claim")
+
+// FeeSchedule is charged on the SOL side of every buy and sell
+type FeeSchedule struct {
+	PlatformBps int64 `json:"platformBps"`
+	CreatorBps  int64 `json:"creatorBps"`
+}
+
+// Fees is the schedule applied to trades
+var Fees = FeeSchedule{PlatformBps: DefaultPlatformFeeBps, CreatorBps: DefaultCreatorFeeBps}
+
+// Validate rejects negative fees and combined fees above MaxFeeBps
+func (f FeeSchedule) Validate() error {
+	if f.PlatformBps < 0 || f.CreatorBps < 0 {
+		return fmt.Errorf("fees must not be negative")
+	}
+	if f.PlatformBps+f.CreatorBps > MaxFeeBps {
+		return fmt.Errorf("combined fees must not exceed %d bps", MaxFeeBps)
+	}
+	return nil
+}
+
+// TotalBps returns the combined fee rate
+func (f FeeSchedule) TotalBps() int64 {
+	return f.PlatformBps + f.CreatorBps
+}
+
+// Charge returns the platform and creator fees on a SOL amount, each rounded up to the lamport
+func (f FeeSchedule) Charge(sol units.Lamports) (platform, creator units.Lamports) {
+	return feeOn(sol, f.PlatformBps), feeOn(sol, f.CreatorBps)
+}
+
+func feeOn(sol units.Lamports, bps int64) units.Lamports {
+	return units.Lamports(units.MulDivCeil(int64(sol), bps, BpsDenominator))
+}
+
+// chargeFees sets the fees and what the trader pays (buy) or receives (sell).
+// Fees round up, so a dust sell can owe more than it returns; it is rejected
+// rather than charging the seller for selling.
+func chargeFees(fill *Fill, fees FeeSchedule) error {
+	fill.PlatformFee, fill.CreatorFee = fees.Charge(fill.SolAmount)
+	fee := fill.PlatformFee + fill.CreatorFee
+	if fill.Side == SideBuy {
+		fill.Total = fill.SolAmount + fee
+		return nil
+	}
+	fill.Total = fill.SolAmount - fee
+	if fill.Total <= 0 {
+		return fmt.Errorf("%w: sell returns nothing after fees", ErrInvalidAmount)
+	}
+	return nil
+}
+
+// TokensForBudget returns how many tokens a buy of budget lamports receives
+// once fees are taken, so that curve cost plus fees never exceeds budget
+func TokensForBudget(coin *models.Coin, curve *blockchain.BondingCurve, budget units.Lamports, fees FeeSchedule) units.Tokens {
+	net := units.Lamports(units.MulDiv(int64(budget), BpsDenominator, BpsDenominator+fees.TotalBps()))
+	for net > 0 {
+		tokens := curve.CalculateTokensForSol(coin.TotalSupply, net)
+		cost := curve.CalculateBuyPrice(coin.TotalSupply, tokens)
+		platform, creator := fees.Charge(cost)
+		// Rounding each fee up can overshoot by a few lamports
+		over := cost + platform + creator - budget
+		if over <= 0 {
+			return tokens
+		}
+		net -= over
+	}
+	return 0
+}
+
+// recordFees writes a trade's fees to the ledger inside the trade's transaction
+func recordFees(tx *gorm.DB, coin *models.Coin, trade *models.Trade) error {
+	entries := make([]models.FeeEntry, 0, 2)
+	if trade.PlatformFee > 0 {
+		entries = append(entries, newFeeEntry(FeeKindPlatform, coin.ID, trade.ID, "", trade.PlatformFee))
+	}
+	if trade.CreatorFee > 0 {
+		entries = append(entries, newFeeEntry(FeeKindCreator, coin.ID, trade.ID, coin.CreatorUserID, trade.CreatorFee))
+	}
+	if len(entries) == 0 {
+		return nil
+	}
+	return tx.Create(&entries).Error
+}
+
+func newFeeEntry(kind, coinID, tradeID, creatorUserID string, amount units.Lamports) models.FeeEntry {
+	return models.FeeEntry{
+		ID:            uuid.New().String(),
+		Kind:          kind,
+		CoinID:        coinID,
+		TradeID:       tradeID,
+		CreatorUserID: creatorUserID,
+		Amount:        amount,
+		CreatedAt:     time.Now(),
+	}
+}
+
+// ClaimableCreatorFees returns fees accrued to the creator user minus what was already claimed
+func ClaimableCreatorFees(db *gorm.DB, userID string) (units.Lamports, error) {
+	var claimable units.Lamports
+	err := db.Model(&models.FeeEntry{}).
+		Where("creator_user_id = ? AND kind IN ?", userID, []string{FeeKindCreator, FeeKindClaim}).
+		Select("COALESCE(SUM(amount), 0)::bigint").Scan(&claimable).Error
+	return claimable, err
+}
+
+// ClaimCreatorFees books a claim of everything the user has accrued as a creator
+// and credits it to their SOL account.
+// The caller owns tx; concurrent claims by the same user wait for each other.
+func ClaimCreatorFees(tx *gorm.DB, userID string) (*models.FeeEntry, error) {
+	if err := tx.Exec("SELECT pg_advisory_xact_lock(hashtext(?))", "creator-fees:"+userID).Error; err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrStorage, err)
+	}
+
+	claimable, err := ClaimableCreatorFees(tx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrStorage, err)
+	}
+	if claimable <= 0 {
+		return nil, ErrNothingToClaim
+	}
+
+	claim := newFeeEntry(FeeKindClaim, "", "", userID, -claimable)
+	if err := tx.Create(&claim).Error; err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrStorage, err)
+	}
+	if _, err := PostSolTransaction(tx, userID, models.SolTransaction{Kind: SolTxFeeClaim, Amount: claimable}); err != nil {
+		return nil, err
+	}
+	return &claim, nil
+}
+
+// CoinFeeSummary totals the fees a coin's trades have generated
+type CoinFeeSummary struct {
+	PlatformBps  int64          `json:"platformBps"`
+	CreatorBps   int64          `json:"creatorBps"`
+	PlatformFees units.Lamports `json:"platformFees"`
+	CreatorFees  units.Lamports `json:"creatorFees"` // Accrued to the coin's creator, claimed or not
+}
+
+// SummarizeCoinFees reads a coin's fee totals from the ledger
+func SummarizeCoinFees(db *gorm.DB, coinID string) (*CoinFeeSummary, error) {
+	var rows []struct {
+		Kind  string
+		Total units.Lamports
+	}
+	err := db.Model(&models.FeeEntry{}).
+		Select("kind, COALESCE(SUM(amount), 0)::bigint AS total").
+		Where("coin_id = ?", coinID).
+		Group("kind").
+		Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+
+	summary := &CoinFeeSummary{PlatformBps: Fees.PlatformBps, CreatorBps: Fees.CreatorBps}
+	for _, row := range rows {
+		switch row.Kind {
+		case FeeKindPlatform:
+			summary.PlatformFees = row.Total
+		case FeeKindCreator:
+			summary.CreatorFees = row.Total
+		}
+	}
+	return summary, nil
+}
@@ -0,0 +1,73 @@
+package trading
+
+import (
+	"fmt"
+	"time"
+
+	"memepump/models"
+	"memepump/units"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Migration job statuses
+const (
+	MigrationPending = "pending"
+	MigrationRunning = "running"
+	MigrationDone    = "done"
+	MigrationFailed  = "failed"
+)
+
+// graduate closes the coin's curve, seeds its in-process pool and enqueues its DEX
+// migration inside the trade transaction. A coin whose seed would open an empty
+// pool keeps trading on its curve and returns no job; a later trade retries.
+func graduate(tx *gorm.DB, coin *models.Coin, now time.Time) (*models.MigrationJob, error) {
+	pool, position := newPool(coin, now)
+	if pool.LPSupply <= 0 {
+		return nil, nil
+	}
+
+	coin.Graduated = true
+	coin.GraduatedAt = now
+	if err := tx.Create(pool).Error; err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrStorage, err)
+	}
+	if err := tx.Create(position).Error; err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrStorage, err)
+	}
+
+	// The seed liquidity stays locked; a longer lock set by the creator is kept
+	until := now.Add(GraduationLockDuration)
+	if LiquidityLockActive(coin, now) && coin.LockUntil.After(until) {
+		until = coin.LockUntil
+	}
+	if _, err := applyLock(tx, coin, GraduationLockActor, until, now); err != nil {
+		return nil, err
+	}
+
+	sol, tokens := PoolSeed(coin)
+	job := models.MigrationJob{
+		ID:             uuid.New().String(),
+		CoinID:         coin.ID,
+		Status:         MigrationPending,
+		SolLiquidity:   sol,
+		TokenLiquidity: tokens,
+		NextAttemptAt:  now,
+	}
+	if err := tx.Create(&job).Error; err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrStorage, err)
+	}
+	return &job, nil
+}
+
+// PoolSeed returns the liquidity a graduated coin moves to its DEX pool: the SOL
+// raised on the curve, paired with enough newly minted tokens to open the pool
+// at the coin's final curve price
+func PoolSeed(coin *models.Coin) (units.Lamports, units.Tokens) {
+	sol := coin.RealSolReserves
+	if sol <= 0 || coin.Price <= 0 {
+		return 0, 0
+	}
+	return sol, units.Tokens(units.MulDiv(int64(sol), units.TokenUnit*units.PriceScale, int64(coin.Price)))
+}
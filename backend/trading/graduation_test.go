@@ -0,0 +1,22 @@
+package trading
+
+import (
+	"testing"
+	"time"
+
+	"memepump/models"
+	"memepump/units"
+)
+
+func TestEmptySeedDoesNotGraduate(t *testing.T) {
+	coin := models.Coin{ID: "c1", TotalSupply: units.Tokens(793100000 * units.TokenUnit), Price: 1}
+
+	// No SOL raised means no pool; graduate must not touch the database
+	job, err := graduate(nil, &coin, time.Now())
+	if err != nil || job != nil {
+		t.Fatalf("graduate = %v, %v; want no job, no error", job, err)
+	}
+	if coin.Graduated || !coin.GraduatedAt.IsZero() {
+		t.Error("coin graduated without a pool to trade on")
+	}
+}
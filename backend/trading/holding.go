@@ -0,0 +1,78 @@
+package trading
+
+import (
+	"errors"
+	"fmt"
+
+	"memepump/models"
+	"memepump/units"
+
+	"gorm.io/gorm"
+)
+
+// DefaultMaxHoldingBps is the holding cap of coins created without one,
+// overridable through MAX_HOLDING_BPS. Zero means no cap.
+var DefaultMaxHoldingBps int64
+
+// ErrHoldingCap rejects buys that would take a user past the coin's holding cap
+var ErrHoldingCap = errors.New("buy exceeds the coin's max wallet holding")
+
+// ErrInvalidHoldingCap rejects a holding cap outside 0-10000 bps
+var ErrInvalidHoldingCap = errors.New("maxHoldingBps must be between 0 and 10000")
+
+// ValidateHoldingCap checks a holding cap in basis points
+func ValidateHoldingCap(bps int64) error {
+	if bps < 0 || bps > BpsDenominator {
+		return ErrInvalidHoldingCap
+	}
+	return nil
+}
+
+// HoldingCap returns the most tokens one user may hold. ok is false when the coin
+// has no cap, or has graduated off its curve.
+func HoldingCap(coin *models.Coin) (limit units.Tokens, ok bool) {
+	if coin.Graduated || coin.MaxHoldingBps <= 0 || coin.MaxHoldingBps >= BpsDenominator {
+		return 0, false
+	}
+	return units.Tokens(units.MulDiv(int64(coin.MaxSupply), coin.MaxHoldingBps, BpsDenominator)), true
+}
+
+// AtHoldingCap reports whether a holding leaves no room for another whole token
+func AtHoldingCap(coin *models.Coin, holding units.Tokens) bool {
+	limit, ok := HoldingCap(coin)
+	return ok && holding+units.TokenUnit > limit
+}
+
+// UserHolding sums the coin held by the wallet, the user's other traded wallets
+// and every wallet linked to the user
+func UserHolding(db *gorm.DB, coinID, userID, wallet string) (units.Tokens, error) {
+	var holding int64
+	err := db.Model(&models.Balance{}).
+		Where("coin_id = ?", coinID).
+		Where("wallet = ? OR user_id = ? OR wallet IN (?)", wallet, userID,
+			db.Model(&models.WalletLink{}).Select("address").Where("user_id = ?", userID)).
+		Select("COALESCE(SUM(amount), 0)").Scan(&holding).Error
+	return units.Tokens(holding), err
+}
+
+// checkHoldingCap rejects a curve buy that takes the buyer past the coin's cap.
+// The coin row is locked, so no other fill on this coin changes the holdings meanwhile.
+func checkHoldingCap(tx *gorm.DB, coin *models.Coin, req *models.TradeRequest, fill *Fill) error {
+	limit, ok := HoldingCap(coin)
+	if !ok || fill.Side != SideBuy {
+		return nil
+	}
+	holding, err := UserHolding(tx, coin.ID, req.UserID, req.Wallet)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrStorage, err)
+	}
+	if holding+fill.Amount > limit {
+		left := limit - holding
+		if left < 0 {
+			left = 0
+		}
+		return fmt.Errorf("%w: wallets may hold at most %.2f%% of supply (%d token units), %d more allowed",
+			ErrHoldingCap, float64(coin.MaxHoldingBps)/100, limit, left)
+	}
+	return nil
+}
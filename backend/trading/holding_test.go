@@ -0,0 +1,28 @@
+package trading
+
+import (
+	"testing"
+
+	"memepump/models"
+	"memepump/units"
+)
+
+func TestHoldingCap(t *testing.T) {
+	coin := models.Coin{MaxSupply: 1000000 * units.TokenUnit, MaxHoldingBps: 200}
+
+	limit, ok := HoldingCap(&coin)
+	if !ok || limit != 20000*units.TokenUnit {
+		t.Fatalf("HoldingCap = %d, %v; want 2%% of supply", limit, ok)
+	}
+	if AtHoldingCap(&coin, limit-2*units.TokenUnit) || !AtHoldingCap(&coin, limit) {
+		t.Error("AtHoldingCap should flag holdings within a token of the cap only")
+	}
+
+	coin.Graduated = true
+	if _, ok := HoldingCap(&coin); ok {
+		t.Error("graduated coin still capped")
+	}
+	if err := ValidateHoldingCap(BpsDenominator + 1); err != ErrInvalidHoldingCap {
+		t.Errorf("ValidateHoldingCap(10001) = %v; want %v", err, ErrInvalidHoldingCap)
+	}
+}
@@ -0,0 +1,167 @@
+package trading
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"memepump/models"
+	"memepump/units"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Launch rule limits
+const (
+	MaxLaunchWindow  = 24 * time.Hour
+	MaxAllowlistSize = 1000
+)
+
+// Launch rule names, reported with rejections
+const (
+	LaunchRuleAllowlist    = "allowlist"
+	LaunchRuleCooldown     = "cooldown"
+	LaunchRuleMaxWalletBuy = "max_wallet_buy"
+)
+
+// ErrInvalidLaunchRules rejects launch rules at coin creation
+var ErrInvalidLaunchRules = errors.New("invalid launch rules")
+
+// LaunchRuleError reports a buy rejected by a coin's launch rules
+type LaunchRuleError struct {
+	Rule       string        `json:"rule"`
+	Reason     string        `json:"reason"`
+	RetryAfter time.Duration `json:"-"` // Until the rule stops blocking this buy
+}
+
+func (e *LaunchRuleError) Error() string {
+	return e.Reason
+}
+
+// ValidateLaunchRules checks the rules of a new coin and deduplicates its allowlist
+func ValidateLaunchRules(rules *models.LaunchRules) error {
+	window := time.Duration(rules.Window) * time.Second
+	allowlistWindow := time.Duration(rules.AllowlistWindow) * time.Second
+	switch {
+	case rules.Window < 0 || window > MaxLaunchWindow, rules.AllowlistWindow < 0 || allowlistWindow > MaxLaunchWindow:
+		return fmt.Errorf("%w: windows must be between 0 and %s", ErrInvalidLaunchRules, MaxLaunchWindow)
+	case rules.MaxWalletBuy < 0 || rules.Cooldown < 0:
+		return fmt.Errorf("%w: maxWalletBuy and cooldownSeconds must not be negative", ErrInvalidLaunchRules)
+	case (rules.MaxWalletBuy > 0 || rules.Cooldown > 0) && rules.Window == 0:
+		return fmt.Errorf("%w: maxWalletBuy and cooldownSeconds need windowSeconds", ErrInvalidLaunchRules)
+	case (len(rules.Allowlist) > 0) != (rules.AllowlistWindow > 0):
+		return fmt.Errorf("%w: an allowlist and allowlistSeconds go together", ErrInvalidLaunchRules)
+	case len(rules.Allowlist) > MaxAllowlistSize:
+		return fmt.Errorf("%w: at most %d allowlisted wallets", ErrInvalidLaunchRules, MaxAllowlistSize)
+	}
+
+	seen := make(map[string]bool, len(rules.Allowlist))
+	wallets := rules.Allowlist[:0]
+	for _, wallet := range rules.Allowlist {
+		if wallet != "" && !seen[wallet] {
+			seen[wallet] = true
+			wallets = append(wallets, wallet)
+		}
+	}
+	rules.Allowlist = wallets
+	rules.AllowlistSize = len(wallets)
+	return nil
+}
+
+// SaveLaunchAllowlist stores a new coin's allowlist
+func SaveLaunchAllowlist(tx *gorm.DB, coinID string, wallets []string) error {
+	if len(wallets) == 0 {
+		return nil
+	}
+	entries := make([]models.LaunchAllowlistEntry, len(wallets))
+	for i, wallet := range wallets {
+		entries[i] = models.LaunchAllowlistEntry{CoinID: coinID, Wallet: wallet}
+	}
+	return tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&entries).Error
+}
+
+// launchHistory is what the launch rules need to know about a wallet's earlier buys
+type launchHistory struct {
+	Allowlisted  bool
+	Bought       bool           // Whether the wallet bought before
+	SinceLastBuy time.Duration  // Time since its last buy
+	Spent        units.Lamports // Spent on buys since launch, fees included
+}
+
+// checkLaunchRules rejects a buy that breaks the coin's launch rules.
+// The coin row is locked, so a wallet's concurrent buys are seen in order.
+func checkLaunchRules(tx *gorm.DB, coin *models.Coin, req *models.TradeRequest, fill *Fill, now time.Time) error {
+	rules := coin.LaunchRules
+	age := now.Sub(coin.CreatedAt)
+	inAllowlist := age < time.Duration(rules.AllowlistWindow)*time.Second
+	inWindow := age < time.Duration(rules.Window)*time.Second
+	if fill.Side != SideBuy || req.Wallet == coin.CreatorWallet || (!inAllowlist && !inWindow) {
+		return nil
+	}
+
+	var history launchHistory
+	if inAllowlist {
+		var count int64
+		if err := tx.Model(&models.LaunchAllowlistEntry{}).
+			Where("coin_id = ? AND wallet = ?", coin.ID, req.Wallet).Count(&count).Error; err != nil {
+			return fmt.Errorf("%w: %v", ErrStorage, err)
+		}
+		history.Allowlisted = count > 0
+	}
+	if inWindow {
+		var last sql.NullTime
+		var spent int64
+		row := tx.Model(&models.Trade{}).
+			Where("coin_id = ? AND wallet = ? AND type = ?", coin.ID, req.Wallet, SideBuy).
+			Select("MAX(timestamp), COALESCE(SUM(sol_amount + platform_fee + creator_fee), 0)").Row()
+		if err := row.Scan(&last, &spent); err != nil {
+			return fmt.Errorf("%w: %v", ErrStorage, err)
+		}
+		history.Bought = last.Valid
+		history.SinceLastBuy = now.Sub(last.Time)
+		history.Spent = units.Lamports(spent)
+	}
+	return launchRuleViolation(rules, age, history, fill.Total)
+}
+
+// launchRuleViolation applies the rules to a buy costing total, age after launch
+func launchRuleViolation(rules models.LaunchRules, age time.Duration, history launchHistory, total units.Lamports) error {
+	allowlistWindow := time.Duration(rules.AllowlistWindow) * time.Second
+	window := time.Duration(rules.Window) * time.Second
+
+	if age < allowlistWindow && !history.Allowlisted {
+		return &LaunchRuleError{
+			Rule:       LaunchRuleAllowlist,
+			Reason:     fmt.Sprintf("only allowlisted wallets can buy during the first %s", allowlistWindow),
+			RetryAfter: allowlistWindow - age,
+		}
+	}
+	if age >= window {
+		return nil
+	}
+
+	cooldown := time.Duration(rules.Cooldown) * time.Second
+	if history.Bought && history.SinceLastBuy < cooldown {
+		return &LaunchRuleError{
+			Rule:       LaunchRuleCooldown,
+			Reason:     fmt.Sprintf("wallets must wait %s between buys during the first %s", cooldown, window),
+			RetryAfter: cooldown - history.SinceLastBuy,
+		}
+	}
+
+	if rules.MaxWalletBuy > 0 && history.Spent+total > rules.MaxWalletBuy {
+		left := rules.MaxWalletBuy - history.Spent
+		if left < 0 {
+			left = 0
+		}
+		return &LaunchRuleError{
+			Rule: LaunchRuleMaxWalletBuy,
+			Reason: fmt.Sprintf("wallets can spend at most %.4f SOL during the first %s, %.4f SOL left",
+				rules.MaxWalletBuy.SOL(), window, left.SOL()),
+			RetryAfter: window - age,
+		}
+	}
+	return nil
+}
@@ -0,0 +1,70 @@
+package trading
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"memepump/models"
+	"memepump/units"
+)
+
+func TestLaunchRuleViolation(t *testing.T) {
+	rules := models.LaunchRules{
+		Window:          600,
+		MaxWalletBuy:    2 * units.LamportsPerSol,
+		Cooldown:        30,
+		AllowlistWindow: 60,
+	}
+	sol := units.Lamports(units.LamportsPerSol)
+
+	tests := []struct {
+		name    string
+		age     time.Duration
+		history launchHistory
+		total   units.Lamports
+		rule    string
+	}{
+		{"allowlist phase, not listed", 10 * time.Second, launchHistory{}, sol, LaunchRuleAllowlist},
+		{"allowlist phase, listed", 10 * time.Second, launchHistory{Allowlisted: true}, sol, ""},
+		{"cooldown", 2 * time.Minute, launchHistory{Bought: true, SinceLastBuy: 10 * time.Second, Spent: sol}, sol, LaunchRuleCooldown},
+		{"after cooldown", 2 * time.Minute, launchHistory{Bought: true, SinceLastBuy: time.Minute, Spent: sol}, sol, ""},
+		{"over the cap", 2 * time.Minute, launchHistory{Bought: true, SinceLastBuy: time.Minute, Spent: sol}, sol + 1, LaunchRuleMaxWalletBuy},
+		{"after the window", 11 * time.Minute, launchHistory{Bought: true, Spent: 10 * sol}, sol, ""},
+	}
+	for _, test := range tests {
+		err := launchRuleViolation(rules, test.age, test.history, test.total)
+		var launch *LaunchRuleError
+		switch {
+		case test.rule == "" && err != nil:
+			t.Errorf("%s: rejected with %v", test.name, err)
+		case test.rule != "" && (!errors.As(err, &launch) || launch.Rule != test.rule):
+			t.Errorf("%s: error = %v; want rule %s", test.name, err, test.rule)
+		case launch != nil && launch.RetryAfter <= 0:
+			t.Errorf("%s: retryAfter = %s; want positive", test.name, launch.RetryAfter)
+		}
+	}
+}
+
+func TestValidateLaunchRules(t *testing.T) {
+	rules := models.LaunchRules{AllowlistWindow: 60, Allowlist: []string{"w1", "w2", "w1", ""}}
+	if err := ValidateLaunchRules(&rules); err != nil {
+		t.Fatalf("valid rules rejected: %v", err)
+	}
+	if rules.AllowlistSize != 2 {
+		t.Errorf("allowlist size = %d; want 2 after deduplication", rules.AllowlistSize)
+	}
+
+	for _, invalid := range []models.LaunchRules{
+		{Window: -1},
+		{Window: int64(MaxLaunchWindow/time.Second) + 1},
+		{MaxWalletBuy: units.LamportsPerSol},
+		{Window: 60, Cooldown: -1},
+		{AllowlistWindow: 60},
+		{Allowlist: []string{"w1"}},
+	} {
+		if err := ValidateLaunchRules(&invalid); !errors.Is(err, ErrInvalidLaunchRules) {
+			t.Errorf("ValidateLaunchRules(%+v) = %v; want %v", invalid, err, ErrInvalidLaunchRules)
+		}
+	}
+}
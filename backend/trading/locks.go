@@ -0,0 +1,136 @@
+package trading
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"memepump/models"
+	"memepump/units"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Lock event actions
+const (
+	LockActionLock   = "lock"
+	LockActionExtend = "extend"
+)
+
+// GraduationLockActor marks locks set automatically when a coin graduates
+const GraduationLockActor = "graduation"
+
+// Lock durations
+const (
+	GraduationLockDuration = 365 * 24 * time.Hour
+	MaxLockDuration        = 10 * 365 * 24 * time.Hour
+)
+
+// Lock errors
+var (
+	ErrLiquidityLocked = errors.New("liquidity is locked")
+	ErrLockShortening  = errors.New("a lock can only be extended")
+	ErrLockInPast      = errors.New("lock must end in the future")
+	ErrLockTooLong     = errors.New("lock exceeds the maximum duration")
+)
+
+// LiquidityLockActive reports whether the coin's liquidity is locked at now
+func LiquidityLockActive(coin *models.Coin, now time.Time) bool {
+	return coin.LiquidityLocked && now.Before(coin.LockUntil)
+}
+
+// LockLiquidity locks or extends the lock on a coin's liquidity until the given time.
+// The caller owns tx.
+func LockLiquidity(tx *gorm.DB, coinID, actor string, until, now time.Time) (*models.Coin, *models.LockEvent, error) {
+	var coin models.Coin
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&coin, "id = ?", coinID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil, ErrCoinNotFound
+		}
+		return nil, nil, fmt.Errorf("%w: %v", ErrStorage, err)
+	}
+
+	event, err := applyLock(tx, &coin, actor, until, now)
+	if err != nil {
+		return nil, nil, err
+	}
+	if err := tx.Save(&coin).Error; err != nil {
+		return nil, nil, fmt.Errorf("%w: %v", ErrStorage, err)
+	}
+	return &coin, event, nil
+}
+
+// applyLock validates a lock on a coin row the caller has locked and records it.
+// The coin itself is saved by the caller.
+func applyLock(tx *gorm.DB, coin *models.Coin, actor string, until, now time.Time) (*models.LockEvent, error) {
+	action, err := lockAction(coin, until, now)
+	if err != nil {
+		return nil, err
+	}
+
+	coin.LiquidityLocked = true
+	coin.LockUntil = until
+	coin.LockedAmount = lockableLiquidity(tx, coin)
+
+	event := models.LockEvent{
+		ID:           uuid.New().String(),
+		CoinID:       coin.ID,
+		Action:       action,
+		Actor:        actor,
+		LockUntil:    until,
+		LockedAmount: coin.LockedAmount,
+		CreatedAt:    now,
+	}
+	if err := tx.Create(&event).Error; err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrStorage, err)
+	}
+	return &event, nil
+}
+
+// lockAction validates a new lock end against the coin's current lock
+func lockAction(coin *models.Coin, until, now time.Time) (string, error) {
+	if !until.After(now) {
+		return "", ErrLockInPast
+	}
+	if until.Sub(now) > MaxLockDuration {
+		return "", ErrLockTooLong
+	}
+	if !LiquidityLockActive(coin, now) {
+		return LockActionLock, nil
+	}
+	if until.Before(coin.LockUntil) {
+		return "", ErrLockShortening
+	}
+	return LockActionExtend, nil
+}
+
+// checkLiquidityLock rejects removing a locked position's shares while the lock lasts.
+// The lock covers the platform's seed liquidity and the creator's own LP shares.
+func checkLiquidityLock(coin *models.Coin, owner string, now time.Time) error {
+	if !LiquidityLockActive(coin, now) {
+		return nil
+	}
+	if owner == PlatformLPOwner || owner == coin.CreatorUserID {
+		return ErrLiquidityLocked
+	}
+	return nil
+}
+
+// lockableLiquidity is the SOL side of the locked liquidity: the locked LP shares
+// once graduated, the curve reserves that will seed the pool before
+func lockableLiquidity(tx *gorm.DB, coin *models.Coin) units.Lamports {
+	if !coin.Graduated {
+		return coin.RealSolReserves
+	}
+	var pool models.Pool
+	if err := tx.First(&pool, "coin_id = ?", coin.ID).Error; err != nil || pool.LPSupply <= 0 {
+		return 0
+	}
+	var shares int64
+	tx.Model(&models.LPPosition{}).
+		Where("coin_id = ? AND owner IN ?", coin.ID, []string{PlatformLPOwner, coin.CreatorUserID}).
+		Select("COALESCE(SUM(shares), 0)").Scan(&shares)
+	return units.Lamports(units.MulDiv(shares, int64(pool.SolReserve), pool.LPSupply))
+}
@@ -0,0 +1,51 @@
+package trading
+
+import (
+	"testing"
+	"time"
+
+	"memepump/models"
+)
+
+func TestLocksOnlyExtend(t *testing.T) {
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	coin := models.Coin{LiquidityLocked: true, LockUntil: now.Add(30 * 24 * time.Hour)}
+
+	tests := []struct {
+		until  time.Time
+		action string
+		err    error
+	}{
+		{now.Add(60 * 24 * time.Hour), LockActionExtend, nil},
+		{coin.LockUntil, LockActionExtend, nil},
+		{now.Add(24 * time.Hour), "", ErrLockShortening},
+		{now.Add(-time.Hour), "", ErrLockInPast},
+		{now.Add(MaxLockDuration + time.Hour), "", ErrLockTooLong},
+	}
+	for _, test := range tests {
+		action, err := lockAction(&coin, test.until, now)
+		if action != test.action || err != test.err {
+			t.Errorf("lockAction(%v) = %q, %v; want %q, %v", test.until, action, err, test.action, test.err)
+		}
+	}
+
+	// An expired lock can be replaced by a shorter one
+	expired := now.Add(31 * 24 * time.Hour)
+	if LiquidityLockActive(&coin, expired) {
+		t.Error("lock still active after LockUntil")
+	}
+	if action, err := lockAction(&coin, expired.Add(time.Hour), expired); action != LockActionLock || err != nil {
+		t.Errorf("relock after expiry = %q, %v; want %q", action, err, LockActionLock)
+	}
+}
+
+func TestLockCoversCreatorByUserID(t *testing.T) {
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	coin := models.Coin{Creator: "MoonBoy", CreatorUserID: "u1", LiquidityLocked: true, LockUntil: now.Add(time.Hour)}
+
+	for owner, want := range map[string]error{PlatformLPOwner: ErrLiquidityLocked, "u1": ErrLiquidityLocked, "MoonBoy": nil, "u2": nil} {
+		if err := checkLiquidityLock(&coin, owner, now); err != want {
+			t.Errorf("checkLiquidityLock(%q) = %v; want %v", owner, err, want)
+		}
+	}
+}
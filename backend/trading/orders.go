@@ -0,0 +1,337 @@
+package trading
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"memepump/models"
+	"memepump/units"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Order types
+const (
+	OrderLimit        = "limit"
+	OrderStopLoss     = "stop_loss"
+	OrderTakeProfit   = "take_profit"
+	OrderTrailingStop = "trailing_stop"
+)
+
+// Order statuses
+const (
+	OrderOpen      = "open"
+	OrderFilled    = "filled"
+	OrderCancelled = "cancelled"
+	OrderFailed    = "failed"
+)
+
+// MaxFillsPerMatch bounds how many orders one matcher run fills, since each fill
+// moves the price and may trigger further orders
+const MaxFillsPerMatch = 100
+
+// Order errors
+var (
+	ErrOrderNotFound    = errors.New("order not found")
+	ErrOrderNotOpen     = errors.New("order is no longer open")
+	ErrInvalidTrigger   = errors.New("triggerPrice must be positive")
+	ErrInvalidOrderKind = errors.New("unknown order type")
+)
+
+// PlaceOrder validates an order and opens it: limit orders through placeLimit,
+// stop-loss, take-profit and trailing stops through placeRule. The caller owns tx.
+func PlaceOrder(tx *gorm.DB, order *models.Order) error {
+	if order.UserID == "" {
+		return ErrNoAccount
+	}
+	if order.Type == "" {
+		order.Type = OrderLimit
+	}
+
+	var coin models.Coin
+	if err := tx.Select("id", "price").First(&coin, "id = ?", order.CoinID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrCoinNotFound
+		}
+		return fmt.Errorf("%w: %v", ErrStorage, err)
+	}
+
+	now := time.Now()
+	order.ID = uuid.New().String()
+	order.Status = OrderOpen
+	order.CreatedAt = now
+	order.UpdatedAt = now
+
+	var err error
+	switch order.Type {
+	case OrderLimit:
+		err = placeLimit(tx, order)
+	case OrderStopLoss, OrderTakeProfit, OrderTrailingStop:
+		err = placeRule(tx, order, &coin)
+	default:
+		err = ErrInvalidOrderKind
+	}
+	if err != nil {
+		return err
+	}
+
+	if err := tx.Create(order).Error; err != nil {
+		return fmt.Errorf("%w: %v", ErrStorage, err)
+	}
+	return nil
+}
+
+// placeLimit reserves a limit order's funds: a buy's SOL budget from the user's
+// account, a sell's tokens from their balance. A sell without an amount offers
+// the wallet's whole unreserved balance.
+func placeLimit(tx *gorm.DB, order *models.Order) error {
+	if order.TriggerPrice <= 0 {
+		return ErrInvalidTrigger
+	}
+
+	if err := checkWalletLinked(tx, order.UserID, order.Wallet); err != nil {
+		return err
+	}
+
+	switch order.Side {
+	case SideBuy:
+		if order.SolAmount <= 0 || order.Amount != 0 {
+			return ErrInvalidAmount
+		}
+		balance, err := lockBalance(tx, order.Wallet, order.CoinID)
+		if err != nil {
+			return err
+		}
+		if err := checkBalance(balance, &models.TradeRequest{Type: SideBuy, UserID: order.UserID}); err != nil {
+			return err
+		}
+		entry := models.SolTransaction{Kind: SolTxReserve, Amount: -order.SolAmount, OrderID: order.ID}
+		if _, err := PostSolTransaction(tx, order.UserID, entry); err != nil {
+			return err
+		}
+	case SideSell:
+		if order.Amount < 0 || order.SolAmount != 0 {
+			return ErrInvalidAmount
+		}
+		balance, err := lockBalance(tx, order.Wallet, order.CoinID)
+		if err != nil {
+			return err
+		}
+		if balance.UserID != order.UserID {
+			if balance.UserID == "" {
+				return ErrInsufficientBalance
+			}
+			return ErrWalletNotOwned
+		}
+		available := balance.Amount - balance.Reserved
+		if order.Amount == 0 {
+			order.Amount = available
+		}
+		if order.Amount <= 0 {
+			return ErrInvalidAmount
+		}
+		if order.Amount > available {
+			return ErrInsufficientBalance
+		}
+		balance.Reserved += order.Amount
+		if err := saveBalance(tx, balance, order.UserID); err != nil {
+			return err
+		}
+	default:
+		return ErrInvalidSide
+	}
+	return nil
+}
+
+// CancelOrder closes one of the user's open orders and releases its reservation
+func CancelOrder(tx *gorm.DB, orderID, userID string) (*models.Order, error) {
+	var order models.Order
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		First(&order, "id = ? AND user_id = ?", orderID, userID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrOrderNotFound
+		}
+		return nil, fmt.Errorf("%w: %v", ErrStorage, err)
+	}
+	if order.Status != OrderOpen {
+		return nil, ErrOrderNotOpen
+	}
+	if err := closeOrder(tx, &order, OrderCancelled, ""); err != nil {
+		return nil, err
+	}
+	return &order, nil
+}
+
+// OrderTriggered reports whether price has crossed the order's trigger.
+// Buys and stops trigger at or below it, limit sells and take-profits at or above.
+func OrderTriggered(order *models.Order, price units.Price) bool {
+	if order.Side == SideBuy || order.Type == OrderStopLoss || order.Type == OrderTrailingStop {
+		return price <= order.TriggerPrice
+	}
+	return price >= order.TriggerPrice
+}
+
+// OrderFill is an order the matcher filled, or failed to fill when Result is nil
+type OrderFill struct {
+	Order  models.Order `json:"order"`
+	Result *Result      `json:"result,omitempty"`
+}
+
+// MatchOrders fills the coin's open orders whose trigger the current price has
+// crossed, oldest first, each in its own transaction through Execute. Orders that
+// fail to fill are closed as failed with their reservation released.
+func MatchOrders(db *gorm.DB, coinID string) ([]OrderFill, error) {
+	var fills []OrderFill
+	for len(fills) < MaxFillsPerMatch {
+		fill, err := matchNext(db, coinID)
+		if err != nil {
+			return fills, err
+		}
+		if fill == nil {
+			break
+		}
+		fills = append(fills, *fill)
+	}
+	return fills, nil
+}
+
+// matchNext fills the oldest triggered order, returning nil when none is left
+func matchNext(db *gorm.DB, coinID string) (*OrderFill, error) {
+	tx := db.Begin()
+	defer tx.Rollback()
+
+	// Lock the coin first, as Execute does, so the price cannot move under the match
+	var coin models.Coin
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&coin, "id = ?", coinID).Error; err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrStorage, err)
+	}
+
+	if err := trailStops(tx, &coin); err != nil {
+		return nil, err
+	}
+
+	// The same conditions as OrderTriggered
+	var order models.Order
+	err := tx.Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+		Where("coin_id = ? AND status = ?", coinID, OrderOpen).
+		Where("(trigger_price >= ? AND (side = ? OR type IN ?)) OR (trigger_price <= ? AND side = ? AND type IN ?)",
+			coin.Price, SideBuy, []string{OrderStopLoss, OrderTrailingStop},
+			coin.Price, SideSell, []string{OrderLimit, OrderTakeProfit}).
+		Order("created_at").First(&order).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrStorage, err)
+	}
+
+	result, err := fillOrder(tx, &order, &coin)
+	if err != nil {
+		if errors.Is(err, ErrStorage) {
+			return nil, err
+		}
+		// Retry the failure outside the rolled back fill, so the reservation is released
+		tx.Rollback()
+		return failOrder(db, order.ID, err)
+	}
+	if err := tx.Commit().Error; err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrStorage, err)
+	}
+	return &OrderFill{Order: order, Result: result}, nil
+}
+
+// fillOrder releases the order's reservation and trades it through Execute
+func fillOrder(tx *gorm.DB, order *models.Order, coin *models.Coin) (*Result, error) {
+	if err := releaseReservation(tx, order); err != nil {
+		return nil, err
+	}
+
+	req := models.TradeRequest{
+		CoinID:   order.CoinID,
+		Type:     order.Side,
+		Wallet:   order.Wallet,
+		Username: order.Username,
+		UserID:   order.UserID,
+	}
+	switch {
+	case order.Type != OrderLimit:
+		if err := sizeRuleSell(tx, order, coin, &req); err != nil {
+			return nil, err
+		}
+	case order.Side == SideBuy:
+		req.SolAmount = order.SolAmount
+	default:
+		req.Amount = order.Amount
+	}
+	result, err := Execute(tx, &req)
+	if err != nil {
+		return nil, err
+	}
+
+	now := result.Trade.Timestamp
+	order.Status = OrderFilled
+	order.TradeID = result.Trade.ID
+	order.FilledAt = &now
+	order.UpdatedAt = now
+	if err := tx.Save(order).Error; err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrStorage, err)
+	}
+	return result, nil
+}
+
+// failOrder closes an order whose fill was rejected
+func failOrder(db *gorm.DB, orderID string, cause error) (*OrderFill, error) {
+	var order models.Order
+	err := db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&order, "id = ?", orderID).Error; err != nil {
+			return fmt.Errorf("%w: %v", ErrStorage, err)
+		}
+		if order.Status != OrderOpen {
+			return nil
+		}
+		return closeOrder(tx, &order, OrderFailed, cause.Error())
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &OrderFill{Order: order}, nil
+}
+
+// closeOrder releases an open order's reservation and stores its final status
+func closeOrder(tx *gorm.DB, order *models.Order, status, reason string) error {
+	if err := releaseReservation(tx, order); err != nil {
+		return err
+	}
+	order.Status = status
+	order.Error = reason
+	order.UpdatedAt = time.Now()
+	if err := tx.Save(order).Error; err != nil {
+		return fmt.Errorf("%w: %v", ErrStorage, err)
+	}
+	return nil
+}
+
+// releaseReservation returns a limit order's reserved SOL or tokens
+func releaseReservation(tx *gorm.DB, order *models.Order) error {
+	if order.Type != OrderLimit {
+		return nil // Position rules reserve nothing
+	}
+	if order.Side == SideBuy {
+		entry := models.SolTransaction{Kind: SolTxRelease, Amount: order.SolAmount, OrderID: order.ID}
+		_, err := PostSolTransaction(tx, order.UserID, entry)
+		return err
+	}
+
+	balance, err := lockBalance(tx, order.Wallet, order.CoinID)
+	if err != nil {
+		return err
+	}
+	balance.Reserved -= order.Amount
+	if balance.Reserved < 0 {
+		balance.Reserved = 0
+	}
+	return saveBalance(tx, balance, order.UserID)
+}
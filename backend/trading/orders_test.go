@@ -0,0 +1,197 @@
+package trading
+
+import (
+	"os"
+	"sync"
+	"testing"
+	"time"
+
+	"memepump/blockchain"
+	"memepump/database"
+	"memepump/models"
+	"memepump/units"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+func TestOrderTriggered(t *testing.T) {
+	trigger := units.PriceFromSol(0.001)
+	buy := &models.Order{Side: SideBuy, TriggerPrice: trigger}
+	sell := &models.Order{Side: SideSell, TriggerPrice: trigger}
+
+	tests := []struct {
+		order *models.Order
+		price units.Price
+		want  bool
+	}{
+		{buy, trigger + 1, false},
+		{buy, trigger, true},
+		{buy, trigger - 1, true},
+		{sell, trigger - 1, false},
+		{sell, trigger, true},
+		{sell, trigger + 1, true},
+	}
+	for _, test := range tests {
+		if got := OrderTriggered(test.order, test.price); got != test.want {
+			t.Errorf("OrderTriggered(%s at %d, price %d) = %v; want %v",
+				test.order.Side, test.order.TriggerPrice, test.price, got, test.want)
+		}
+	}
+}
+
+func TestRuleTriggers(t *testing.T) {
+	entry := units.PriceFromSol(0.001)
+	spot := units.PriceFromSol(0.0012)
+
+	tests := []struct {
+		order models.Order
+		want  units.Price
+		err   error
+	}{
+		{models.Order{Type: OrderStopLoss, TriggerPercent: 10}, entry * 9 / 10, nil},
+		{models.Order{Type: OrderTakeProfit, TriggerPercent: 50}, entry * 3 / 2, nil},
+		{models.Order{Type: OrderTakeProfit, TriggerPrice: spot * 2}, spot * 2, nil},
+		{models.Order{Type: OrderTrailingStop, TriggerPercent: 25}, spot * 3 / 4, nil},
+		{models.Order{Type: OrderStopLoss, TriggerPercent: 100}, 0, ErrRuleTrigger},
+		{models.Order{Type: OrderStopLoss, TriggerPrice: entry, TriggerPercent: 10}, 0, ErrRuleTrigger},
+		{models.Order{Type: OrderTrailingStop, TriggerPrice: entry}, 0, ErrRuleTrigger},
+		{models.Order{Type: OrderStopLoss, TriggerPrice: spot}, 0, ErrRuleWouldFire},
+		{models.Order{Type: OrderTakeProfit, TriggerPercent: 10}, 0, ErrRuleWouldFire},
+	}
+	for _, test := range tests {
+		order := test.order
+		err := setRuleTrigger(&order, entry, spot)
+		if err != test.err || (err == nil && order.TriggerPrice != test.want) {
+			t.Errorf("setRuleTrigger(%s %+v) = %d, %v; want %d, %v",
+				test.order.Type, test.order, order.TriggerPrice, err, test.want, test.err)
+		}
+	}
+
+	if err := setRuleTrigger(&models.Order{Type: OrderStopLoss, TriggerPercent: 10}, 0, spot); err != ErrNoEntryPrice {
+		t.Errorf("percent stop without entry = %v; want %v", err, ErrNoEntryPrice)
+	}
+
+	// Stops trigger on the way down, take-profits on the way up
+	stop := &models.Order{Side: SideSell, Type: OrderTrailingStop, TriggerPrice: entry}
+	if !OrderTriggered(stop, entry-1) || OrderTriggered(stop, entry+1) {
+		t.Error("trailing stop should trigger at or below its price only")
+	}
+}
+
+func TestRuleMinReturnFromReference(t *testing.T) {
+	withFees(t, FeeSchedule{PlatformBps: 100, CreatorBps: 50})
+	price := units.PriceFromSol(0.001)
+	amount := units.Tokens(1000 * units.TokenUnit)
+
+	// 1 SOL at the reference, less 1.5% fees, less 5% slippage
+	stop := &models.Order{Type: OrderStopLoss, TriggerPrice: price, SlippageBps: 500}
+	if got, want := ruleMinReturn(stop, nil, amount), units.Lamports(935750000); got != want {
+		t.Errorf("stop-loss bound = %d; want %d", got, want)
+	}
+
+	// A trailing stop trails its peak
+	trailing := &models.Order{Type: OrderTrailingStop, PeakPrice: price * 2, TriggerPercent: 50, SlippageBps: 500}
+	if got, want := ruleMinReturn(trailing, nil, amount), units.Lamports(935750000); got != want {
+		t.Errorf("trailing stop bound = %d; want %d", got, want)
+	}
+
+	// A pool sell also pays the swap fee
+	_, pool := testPool()
+	if got, want := ruleMinReturn(stop, pool, amount), units.Lamports(933410625); got != want {
+		t.Errorf("pool stop-loss bound = %d; want %d", got, want)
+	}
+}
+
+var connectOnce sync.Once
+
+// testDB connects to the Postgres at TEST_DATABASE_URL, migrated like the
+// server's, skipping the test without one. Trades are append-only, so point it
+// at a scratch database.
+func testDB(t *testing.T) *gorm.DB {
+	dsn := os.Getenv("TEST_DATABASE_URL")
+	if dsn == "" {
+		t.Skip("TEST_DATABASE_URL not set")
+	}
+	connectOnce.Do(func() {
+		database.Connect(dsn)
+		database.DB.Logger = logger.Default.LogMode(logger.Silent)
+	})
+	return database.DB
+}
+
+// testTrader creates a user with a linked wallet and SOL to trade with
+func testTrader(t *testing.T, db *gorm.DB) models.TradeRequest {
+	userID := uuid.New().String()
+	wallet := "test-" + userID
+	err := db.Transaction(func(tx *gorm.DB) error {
+		link := models.WalletLink{ID: uuid.New().String(), UserID: userID, Address: wallet, Chain: "solana", IsPrimary: true, CreatedAt: time.Now()}
+		if err := tx.Create(&link).Error; err != nil {
+			return err
+		}
+		_, err := PostSolTransaction(tx, userID, models.SolTransaction{Kind: SolTxDeposit, Amount: 1000000 * units.LamportsPerSol})
+		return err
+	})
+	if err != nil {
+		t.Fatalf("create trader: %v", err)
+	}
+	return models.TradeRequest{UserID: userID, Wallet: wallet}
+}
+
+func TestGappedStopFailsInsteadOfFilling(t *testing.T) {
+	db := testDB(t)
+	withFees(t, FeeSchedule{PlatformBps: 100, CreatorBps: 50})
+
+	coin := models.Coin{ID: uuid.New().String(), Name: "Gap", Symbol: "GAP", CreatedAt: time.Now()}
+	InitCoin(&coin, blockchain.DefaultCurve())
+	if err := db.Create(&coin).Error; err != nil {
+		t.Fatalf("create coin: %v", err)
+	}
+	holder, whale := testTrader(t, db), testTrader(t, db)
+	trade := func(trader models.TradeRequest, side string, amount units.Tokens) {
+		t.Helper()
+		req := trader
+		req.CoinID, req.Type, req.Amount = coin.ID, side, amount
+		if err := db.Transaction(func(tx *gorm.DB) error {
+			_, err := Execute(tx, &req)
+			return err
+		}); err != nil {
+			t.Fatalf("%s %d: %v", side, amount, err)
+		}
+	}
+
+	// The holder buys in after the whale and stops out 10% below entry
+	trade(whale, SideBuy, 200000000*units.TokenUnit)
+	trade(holder, SideBuy, 10000000*units.TokenUnit)
+	stop := models.Order{CoinID: coin.ID, UserID: holder.UserID, Wallet: holder.Wallet, Type: OrderStopLoss, TriggerPercent: 10}
+	if err := db.Transaction(func(tx *gorm.DB) error { return PlaceOrder(tx, &stop) }); err != nil {
+		t.Fatalf("place stop: %v", err)
+	}
+
+	// The whale's dump gaps the price far below the stop before it can match
+	trade(whale, SideSell, 200000000*units.TokenUnit)
+	if err := db.First(&coin, "id = ?", coin.ID).Error; err != nil {
+		t.Fatalf("reload coin: %v", err)
+	}
+	if coin.Price > stop.TriggerPrice*3/4 {
+		t.Fatalf("price %d did not gap below the stop at %d", coin.Price, stop.TriggerPrice)
+	}
+
+	if _, err := MatchOrders(db, coin.ID); err != nil {
+		t.Fatalf("MatchOrders: %v", err)
+	}
+	if err := db.First(&stop, "id = ?", stop.ID).Error; err != nil {
+		t.Fatalf("reload stop: %v", err)
+	}
+	if stop.Status != OrderFailed || stop.TradeID != "" {
+		t.Errorf("gapped stop is %s with trade %q; want %s", stop.Status, stop.TradeID, OrderFailed)
+	}
+	var balance models.Balance
+	if err := db.First(&balance, "wallet = ? AND coin_id = ?", holder.Wallet, coin.ID).Error; err != nil {
+		t.Fatalf("load balance: %v", err)
+	}
+	if balance.Amount != 10000000*units.TokenUnit {
+		t.Errorf("holder has %d tokens after the failed stop; want all 10M", balance.Amount)
+	}
+}
@@ -0,0 +1,365 @@
+package trading
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"time"
+
+	"memepump/models"
+	"memepump/units"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// DefaultSwapFeeBps is the pool fee kept for LPs, overridable through SWAP_FEE_BPS
+const DefaultSwapFeeBps = 25
+
+// SwapFeeBps is the fee new pools are created with
+var SwapFeeBps int64 = DefaultSwapFeeBps
+
+// Liquidity event actions
+const (
+	LiquidityAdd    = "add"
+	LiquidityRemove = "remove"
+)
+
+// PlatformLPOwner owns the liquidity seeded at graduation
+const PlatformLPOwner = "platform"
+
+// Pool errors
+var (
+	ErrPoolNotFound          = errors.New("coin has no pool")
+	ErrInsufficientLiquidity = errors.New("insufficient pool liquidity")
+	ErrInsufficientShares    = errors.New("insufficient LP shares")
+)
+
+// newPool seeds a graduated coin's pool from its curve reserves
+func newPool(coin *models.Coin, now time.Time) (*models.Pool, *models.LPPosition) {
+	sol, tokens := PoolSeed(coin)
+	shares := new(big.Int).Mul(big.NewInt(int64(sol)), big.NewInt(int64(tokens)))
+	shares.Sqrt(shares)
+
+	pool := &models.Pool{
+		CoinID:       coin.ID,
+		SolReserve:   sol,
+		TokenReserve: tokens,
+		LPSupply:     shares.Int64(),
+		FeeBps:       SwapFeeBps,
+		CreatedAt:    now,
+		UpdatedAt:    now,
+	}
+	position := &models.LPPosition{
+		CoinID:    coin.ID,
+		Owner:     PlatformLPOwner,
+		Shares:    pool.LPSupply,
+		UpdatedAt: now,
+	}
+	return pool, position
+}
+
+// PoolPrice returns the pool's spot price
+func PoolPrice(pool *models.Pool) units.Price {
+	return units.PriceOf(pool.SolReserve, pool.TokenReserve)
+}
+
+// poolBuyCost returns the SOL, swap fee included, that takes amount tokens out of the pool
+func poolBuyCost(pool *models.Pool, amount units.Tokens) units.Lamports {
+	// x * y = k on the SOL left after the swap fee, rounded against the trader
+	net := units.MulDivCeil(int64(pool.SolReserve), int64(amount), int64(pool.TokenReserve-amount))
+	return units.Lamports(units.MulDivCeil(net, BpsDenominator, BpsDenominator-pool.FeeBps))
+}
+
+// poolSellReturn returns the SOL paid out for selling amount tokens into the pool
+func poolSellReturn(pool *models.Pool, amount units.Tokens) units.Lamports {
+	in := units.MulDiv(int64(amount), BpsDenominator-pool.FeeBps, BpsDenominator)
+	return units.Lamports(units.MulDiv(int64(pool.SolReserve), in, int64(pool.TokenReserve)+in))
+}
+
+// Swap prices a trade against the pool, charges Fees and applies it to the pool and coin.
+// The swap fee stays in the pool's reserves; platform and creator fees are charged as on the curve.
+func Swap(coin *models.Coin, pool *models.Pool, side string, amount units.Tokens) (*Fill, error) {
+	if amount <= 0 {
+		return nil, ErrInvalidAmount
+	}
+
+	fill := &Fill{
+		Side:         side,
+		Amount:       amount,
+		SupplyBefore: coin.TotalSupply,
+		PriceBefore:  PoolPrice(pool),
+	}
+
+	switch side {
+	case SideBuy:
+		if amount >= pool.TokenReserve {
+			return nil, ErrInsufficientLiquidity
+		}
+		fill.SolAmount = poolBuyCost(pool, amount)
+	case SideSell:
+		if amount > coin.TotalSupply {
+			return nil, ErrInsufficientSupply
+		}
+		fill.SolAmount = poolSellReturn(pool, amount)
+	default:
+		return nil, ErrInvalidSide
+	}
+	if err := chargeFees(fill, Fees); err != nil {
+		return nil, err
+	}
+
+	if side == SideBuy {
+		pool.SolReserve += fill.SolAmount
+		pool.TokenReserve -= amount
+		coin.TotalSupply += amount
+	} else {
+		pool.SolReserve -= fill.SolAmount
+		pool.TokenReserve += amount
+		coin.TotalSupply -= amount
+	}
+	fill.AvgPrice = units.PriceOf(fill.SolAmount, amount)
+	fill.SupplyAfter = coin.TotalSupply
+	fill.PriceAfter = PoolPrice(pool)
+
+	refreshPoolCoin(coin, pool)
+	return fill, nil
+}
+
+// PoolTokensForBudget returns how many tokens a pool buy of budget lamports receives,
+// fees included, mirroring TokensForBudget on the curve
+func PoolTokensForBudget(pool *models.Pool, budget units.Lamports, fees FeeSchedule) units.Tokens {
+	net := units.MulDiv(int64(budget), BpsDenominator, BpsDenominator+fees.TotalBps())
+	for net > 0 {
+		in := units.MulDiv(net, BpsDenominator-pool.FeeBps, BpsDenominator)
+		tokens := units.Tokens(units.MulDiv(int64(pool.TokenReserve), in, int64(pool.SolReserve)+in))
+		if tokens >= pool.TokenReserve {
+			tokens = pool.TokenReserve - 1
+		}
+		if tokens <= 0 {
+			return 0
+		}
+		cost := poolBuyCost(pool, tokens)
+		platform, creator := fees.Charge(cost)
+		over := int64(cost + platform + creator - budget)
+		if over <= 0 {
+			return tokens
+		}
+		net -= over
+	}
+	return 0
+}
+
+// refreshPoolCoin prices a graduated coin from its pool
+func refreshPoolCoin(coin *models.Coin, pool *models.Pool) {
+	coin.Price = PoolPrice(pool)
+	coin.MarketCap = units.Value(coin.TotalSupply, coin.Price)
+	coin.Progress = 100
+}
+
+// lockPool loads a coin's pool for update
+func lockPool(tx *gorm.DB, coinID string) (*models.Pool, error) {
+	var pool models.Pool
+	err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&pool, "coin_id = ?", coinID).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrPoolNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrStorage, err)
+	}
+	return &pool, nil
+}
+
+// ========================================
+// Liquidity
+// ========================================
+
+// LiquidityResult is the outcome of adding or removing liquidity
+type LiquidityResult struct {
+	Pool     models.Pool       `json:"pool"`
+	Position models.LPPosition `json:"position"`
+	Sol      units.Lamports    `json:"sol"`
+	Tokens   units.Tokens      `json:"tokens"`
+	Shares   int64             `json:"shares,string"`
+}
+
+// AddLiquidity deposits sol and the matching tokens from wallet into the coin's pool.
+// The caller owns tx.
+func AddLiquidity(tx *gorm.DB, coinID, userID, wallet string, sol units.Lamports) (*LiquidityResult, error) {
+	if sol <= 0 {
+		return nil, ErrInvalidAmount
+	}
+	coin, pool, err := lockCoinAndPool(tx, coinID)
+	if err != nil {
+		return nil, err
+	}
+
+	tokens := units.Tokens(units.MulDivCeil(int64(sol), int64(pool.TokenReserve), int64(pool.SolReserve)))
+	shares := units.MulDiv(int64(sol), pool.LPSupply, int64(pool.SolReserve))
+	if shares <= 0 {
+		return nil, ErrInvalidAmount
+	}
+
+	if err := checkWalletLinked(tx, userID, wallet); err != nil {
+		return nil, err
+	}
+	balance, err := lockBalance(tx, wallet, coinID)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkBalance(balance, &models.TradeRequest{Type: SideSell, Amount: tokens, UserID: userID}); err != nil {
+		return nil, err
+	}
+	if _, err := PostSolTransaction(tx, userID, models.SolTransaction{Kind: SolTxLPAdd, Amount: -sol}); err != nil {
+		return nil, err
+	}
+
+	coin.Holders += applyFill(balance, &Fill{Side: SideSell, Amount: tokens})
+	coin.TotalSupply -= tokens
+	pool.SolReserve += sol
+	pool.TokenReserve += tokens
+	pool.LPSupply += shares
+
+	position, err := lockPosition(tx, coinID, userID)
+	if err != nil {
+		return nil, err
+	}
+	position.Shares += shares
+
+	if err := recordLiquidity(tx, coin, LiquidityAdd, userID, wallet, sol, tokens, shares); err != nil {
+		return nil, err
+	}
+	if err := saveLiquidity(tx, coin, pool, position, balance, userID); err != nil {
+		return nil, err
+	}
+	return &LiquidityResult{Pool: *pool, Position: *position, Sol: sol, Tokens: tokens, Shares: shares}, nil
+}
+
+// RemoveLiquidity burns shares and pays out their part of both reserves to the user's
+// SOL account and wallet. The caller owns tx.
+func RemoveLiquidity(tx *gorm.DB, coinID, userID, wallet string, shares int64) (*LiquidityResult, error) {
+	if shares <= 0 {
+		return nil, ErrInvalidAmount
+	}
+	coin, pool, err := lockCoinAndPool(tx, coinID)
+	if err != nil {
+		return nil, err
+	}
+
+	position, err := lockPosition(tx, coinID, userID)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkLiquidityLock(coin, userID, time.Now()); err != nil {
+		return nil, err
+	}
+	if shares > position.Shares {
+		return nil, ErrInsufficientShares
+	}
+	if shares >= pool.LPSupply {
+		return nil, ErrInsufficientLiquidity // The pool must never be emptied
+	}
+
+	sol := units.Lamports(units.MulDiv(shares, int64(pool.SolReserve), pool.LPSupply))
+	tokens := units.Tokens(units.MulDiv(shares, int64(pool.TokenReserve), pool.LPSupply))
+
+	if err := checkWalletLinked(tx, userID, wallet); err != nil {
+		return nil, err
+	}
+	balance, err := lockBalance(tx, wallet, coinID)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkBalance(balance, &models.TradeRequest{Type: SideBuy, UserID: userID}); err != nil {
+		return nil, err
+	}
+	if _, err := PostSolTransaction(tx, userID, models.SolTransaction{Kind: SolTxLPRemove, Amount: sol}); err != nil {
+		return nil, err
+	}
+
+	coin.Holders += applyFill(balance, &Fill{Side: SideBuy, Amount: tokens})
+	coin.TotalSupply += tokens
+	pool.SolReserve -= sol
+	pool.TokenReserve -= tokens
+	pool.LPSupply -= shares
+	position.Shares -= shares
+
+	if err := recordLiquidity(tx, coin, LiquidityRemove, userID, wallet, sol, tokens, shares); err != nil {
+		return nil, err
+	}
+	if err := saveLiquidity(tx, coin, pool, position, balance, userID); err != nil {
+		return nil, err
+	}
+	return &LiquidityResult{Pool: *pool, Position: *position, Sol: sol, Tokens: tokens, Shares: shares}, nil
+}
+
+// lockCoinAndPool locks a graduated coin and its pool in the same order as Execute
+func lockCoinAndPool(tx *gorm.DB, coinID string) (*models.Coin, *models.Pool, error) {
+	var coin models.Coin
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&coin, "id = ?", coinID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil, ErrCoinNotFound
+		}
+		return nil, nil, fmt.Errorf("%w: %v", ErrStorage, err)
+	}
+	pool, err := lockPool(tx, coinID)
+	if err != nil {
+		return nil, nil, err
+	}
+	return &coin, pool, nil
+}
+
+// lockPosition loads an owner's LP position for update, or an empty one
+func lockPosition(tx *gorm.DB, coinID, owner string) (*models.LPPosition, error) {
+	var position models.LPPosition
+	err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		First(&position, "coin_id = ? AND owner = ?", coinID, owner).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return &models.LPPosition{CoinID: coinID, Owner: owner}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrStorage, err)
+	}
+	return &position, nil
+}
+
+// recordLiquidity appends a liquidity event to the coin's log. The coin row is
+// locked, so events are numbered in commit order.
+func recordLiquidity(tx *gorm.DB, coin *models.Coin, action, userID, wallet string, sol units.Lamports, tokens units.Tokens, shares int64) error {
+	coin.LiquiditySeq++
+	event := models.LiquidityEvent{
+		ID:        uuid.New().String(),
+		CoinID:    coin.ID,
+		Seq:       coin.LiquiditySeq,
+		TradeSeq:  coin.TradeSeq,
+		Action:    action,
+		UserID:    userID,
+		Wallet:    wallet,
+		Sol:       sol,
+		Tokens:    tokens,
+		Shares:    shares,
+		CreatedAt: time.Now(),
+	}
+	if err := tx.Create(&event).Error; err != nil {
+		return fmt.Errorf("%w: %v", ErrStorage, err)
+	}
+	return nil
+}
+
+func saveLiquidity(tx *gorm.DB, coin *models.Coin, pool *models.Pool, position *models.LPPosition, balance *models.Balance, userID string) error {
+	now := time.Now()
+	pool.UpdatedAt = now
+	position.UpdatedAt = now
+	refreshPoolCoin(coin, pool)
+
+	if err := saveBalance(tx, balance, userID); err != nil {
+		return err
+	}
+	for _, row := range []interface{}{coin, pool, position} {
+		if err := tx.Save(row).Error; err != nil {
+			return fmt.Errorf("%w: %v", ErrStorage, err)
+		}
+	}
+	return nil
+}
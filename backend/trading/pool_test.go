@@ -0,0 +1,99 @@
+package trading
+
+import (
+	"errors"
+	"math/big"
+	"testing"
+
+	"memepump/models"
+	"memepump/units"
+)
+
+func testPool() (*models.Coin, *models.Pool) {
+	coin := &models.Coin{ID: "c1", Graduated: true, TotalSupply: units.Tokens(793100000 * units.TokenUnit)}
+	pool := &models.Pool{
+		CoinID:       "c1",
+		SolReserve:   units.Lamports(85 * units.LamportsPerSol),
+		TokenReserve: units.Tokens(206900000 * units.TokenUnit),
+		LPSupply:     1000000,
+		FeeBps:       25,
+	}
+	return coin, pool
+}
+
+func poolK(pool *models.Pool) *big.Int {
+	return new(big.Int).Mul(big.NewInt(int64(pool.SolReserve)), big.NewInt(int64(pool.TokenReserve)))
+}
+
+func TestSwapRoundTripNeverCreatesValue(t *testing.T) {
+	withFees(t, FeeSchedule{})
+
+	for _, amount := range []units.Tokens{1, 999, 1000000 * units.TokenUnit, 200000000 * units.TokenUnit} {
+		coin, pool := testPool()
+		k := poolK(pool)
+
+		buy, err := Swap(coin, pool, SideBuy, amount)
+		if err != nil {
+			t.Fatalf("buy %d: %v", amount, err)
+		}
+		sell, err := Swap(coin, pool, SideSell, amount)
+		if errors.Is(err, ErrInvalidAmount) && buy.Total > 0 {
+			continue // Dust that returns nothing cannot be sold back
+		}
+		if err != nil {
+			t.Fatalf("sell %d: %v", amount, err)
+		}
+		if sell.Total > buy.Total {
+			t.Errorf("round trip of %d paid %d and returned %d", amount, buy.Total, sell.Total)
+		}
+		if poolK(pool).Cmp(k) < 0 {
+			t.Errorf("round trip of %d shrank k", amount)
+		}
+	}
+}
+
+func TestSwapRejectsDrainingThePool(t *testing.T) {
+	coin, pool := testPool()
+	if _, err := Swap(coin, pool, SideBuy, pool.TokenReserve); err != ErrInsufficientLiquidity {
+		t.Errorf("buying the whole reserve = %v; want %v", err, ErrInsufficientLiquidity)
+	}
+	if _, err := Swap(coin, pool, SideSell, coin.TotalSupply+1); err != ErrInsufficientSupply {
+		t.Errorf("selling beyond supply = %v; want %v", err, ErrInsufficientSupply)
+	}
+}
+
+func TestPoolTokensForBudgetStaysWithinBudget(t *testing.T) {
+	withFees(t, FeeSchedule{PlatformBps: 100, CreatorBps: 50})
+	_, pool := testPool()
+
+	for _, budget := range []units.Lamports{1000, units.LamportsPerSol, 500 * units.LamportsPerSol} {
+		tokens := PoolTokensForBudget(pool, budget, Fees)
+		cost := poolBuyCost(pool, tokens)
+		platform, creator := Fees.Charge(cost)
+		if cost+platform+creator > budget {
+			t.Errorf("budget %d bought %d tokens costing %d", budget, tokens, cost+platform+creator)
+		}
+		if more := poolBuyCost(pool, tokens+tokens/1000+1); more <= budget*9/10 {
+			t.Errorf("budget %d left too much unspent buying %d tokens", budget, tokens)
+		}
+	}
+}
+
+func TestNewPoolSeedsFromCurveReserves(t *testing.T) {
+	coin := &models.Coin{ID: "c1", RealSolReserves: 4 * units.LamportsPerSol, Price: units.PriceFromSol(0.000001)}
+	pool, position := newPool(coin, coin.CreatedAt)
+
+	if pool.SolReserve != coin.RealSolReserves || pool.TokenReserve != 4000000*units.TokenUnit {
+		t.Errorf("pool reserves = %d SOL, %d tokens; want 4 SOL and 4M tokens", pool.SolReserve, pool.TokenReserve)
+	}
+	if PoolPrice(pool) != coin.Price {
+		t.Errorf("pool price = %d; want %d", PoolPrice(pool), coin.Price)
+	}
+	// sqrt(4e9 lamports * 4e12 base units)
+	if pool.LPSupply != 126491106406 {
+		t.Errorf("LP supply = %d; want sqrt of the reserves product", pool.LPSupply)
+	}
+	if position.Owner != PlatformLPOwner || position.Shares != pool.LPSupply {
+		t.Errorf("seed position = %+v; want all shares to the platform", position)
+	}
+}
@@ -0,0 +1,84 @@
+package trading
+
+import (
+	"math"
+	"time"
+
+	"memepump/blockchain"
+	"memepump/models"
+	"memepump/units"
+)
+
+// QuoteTTL is how long a quote's price is meant to be honoured by clients
+const QuoteTTL = 30 * time.Second
+
+// Quote is a non-binding preview of a trade against the coin's current curve state
+type Quote struct {
+	CoinID string `json:"coinId"`
+	Fill
+	PriceImpact   float64        `json:"priceImpact"`   // Percent between spot price and average fill
+	Fee           units.Lamports `json:"fee"`           // Platform plus creator fee
+	ProgressAfter float64        `json:"progressAfter"` // Graduation progress after the trade
+	ExpiresAt     time.Time      `json:"expiresAt"`     // Pass back as quoteExpiresAt when trading
+}
+
+// NewQuote prices a token-denominated trade. The coin is passed by value and never mutated.
+func NewQuote(coin models.Coin, curve *blockchain.BondingCurve, side string, amount units.Tokens) (*Quote, error) {
+	fill, err := Settle(&coin, curve, side, amount)
+	if err != nil {
+		return nil, err
+	}
+	return newQuote(&coin, fill), nil
+}
+
+// NewSolQuote prices a buy that spends solAmount including fees, returning the tokens it would receive
+func NewSolQuote(coin models.Coin, curve *blockchain.BondingCurve, solAmount units.Lamports) (*Quote, error) {
+	if solAmount <= 0 {
+		return nil, ErrInvalidAmount
+	}
+
+	tokens := TokensForBudget(&coin, curve, solAmount, Fees)
+	if tokens <= 0 {
+		return nil, ErrExceedsMaxSupply
+	}
+
+	return NewQuote(coin, curve, SideBuy, tokens)
+}
+
+// NewPoolQuote prices a trade on a graduated coin's pool. Coin and pool are never mutated.
+func NewPoolQuote(coin models.Coin, pool models.Pool, side string, amount units.Tokens) (*Quote, error) {
+	fill, err := Swap(&coin, &pool, side, amount)
+	if err != nil {
+		return nil, err
+	}
+	return newQuote(&coin, fill), nil
+}
+
+// NewPoolSolQuote prices a pool buy that spends solAmount including fees
+func NewPoolSolQuote(coin models.Coin, pool models.Pool, solAmount units.Lamports) (*Quote, error) {
+	if solAmount <= 0 {
+		return nil, ErrInvalidAmount
+	}
+
+	tokens := PoolTokensForBudget(&pool, solAmount, Fees)
+	if tokens <= 0 {
+		return nil, ErrInsufficientLiquidity
+	}
+
+	return NewPoolQuote(coin, pool, SideBuy, tokens)
+}
+
+// newQuote wraps a fill settled on a copy of the coin
+func newQuote(coin *models.Coin, fill *Fill) *Quote {
+	quote := &Quote{
+		CoinID:        coin.ID,
+		Fill:          *fill,
+		Fee:           fill.PlatformFee + fill.CreatorFee,
+		ProgressAfter: coin.Progress,
+		ExpiresAt:     time.Now().Add(QuoteTTL),
+	}
+	if fill.PriceBefore > 0 {
+		quote.PriceImpact = math.Abs(float64(fill.AvgPrice-fill.PriceBefore)) / float64(fill.PriceBefore) * 100
+	}
+	return quote
+}
@@ -0,0 +1,256 @@
+package trading
+
+import (
+	"errors"
+	"fmt"
+	"math"
+
+	"memepump/models"
+	"memepump/units"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Replay is a coin's state folded from its event log: its trades, and the
+// liquidity events of its pool once graduated
+type Replay struct {
+	Coin     models.Coin
+	Pool     *models.Pool // Nil until the log graduates the coin
+	Problems []string     // Events the fold could not reproduce; the state is not trustworthy
+}
+
+// Divergence is a stored field that differs from the replayed state
+type Divergence struct {
+	Field   string `json:"field"`
+	Stored  string `json:"stored"`
+	Derived string `json:"derived"`
+}
+
+// ReplayCoin folds a coin's trades and liquidity events, each in seq order, from
+// its launch state. The stored coin supplies the curve and the stored pool its
+// swap fee; their mutable state is ignored. Trades are re-settled through the
+// same Settle and Swap steps Execute uses, so a fill the curve no longer
+// reproduces is reported as a problem.
+func ReplayCoin(stored *models.Coin, pool *models.Pool, trades []models.Trade, events []models.LiquidityEvent) (*Replay, error) {
+	curve, err := CurveForCoin(stored)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidCurve, err)
+	}
+
+	r := &Replay{Coin: *stored}
+	coin := &r.Coin
+	InitCoin(coin, curve)
+	coin.Holders = 0
+	coin.TradeSeq = 0
+	coin.LiquiditySeq = 0
+	coin.Graduated = false
+
+	positions := make(map[string]units.Tokens)
+	next := 0
+	// applyLiquidity folds the liquidity events that happened after trade seq upTo
+	applyLiquidity := func(upTo int64) bool {
+		for ; next < len(events) && events[next].TradeSeq <= upTo; next++ {
+			event := &events[next]
+			if event.Seq != coin.LiquiditySeq+1 {
+				r.problem("liquidity event %d follows %d", event.Seq, coin.LiquiditySeq)
+			}
+			coin.LiquiditySeq = event.Seq
+			if r.Pool == nil {
+				r.problem("liquidity event %d before the coin graduated", event.Seq)
+				return false
+			}
+
+			sol, tokens, shares := event.Sol, event.Tokens, event.Shares
+			if event.Action == LiquidityRemove {
+				sol, tokens, shares = -sol, -tokens, -shares
+			}
+			coin.TotalSupply -= tokens
+			positions[event.Wallet] -= tokens
+			r.Pool.SolReserve += sol
+			r.Pool.TokenReserve += tokens
+			r.Pool.LPSupply += shares
+			refreshPoolCoin(coin, r.Pool)
+		}
+		return true
+	}
+
+	for i := range trades {
+		trade := &trades[i]
+		if !applyLiquidity(coin.TradeSeq) {
+			return r, nil
+		}
+		if trade.Seq != coin.TradeSeq+1 {
+			r.problem("trade %d follows %d", trade.Seq, coin.TradeSeq)
+		}
+		coin.TradeSeq = trade.Seq
+
+		var fill *Fill
+		var err error
+		if trade.Venue == VenuePool {
+			if r.Pool == nil {
+				r.problem("trade %d on a pool before the coin graduated", trade.Seq)
+				return r, nil
+			}
+			fill, err = Swap(coin, r.Pool, trade.Type, trade.Amount)
+		} else {
+			if coin.Graduated {
+				r.problem("trade %d on the curve after the coin graduated", trade.Seq)
+				return r, nil
+			}
+			fill, err = Settle(coin, curve, trade.Type, trade.Amount)
+		}
+		if err != nil {
+			r.problem("trade %d: %v", trade.Seq, err)
+			return r, nil
+		}
+		if fill.SolAmount != trade.SolAmount {
+			r.problem("trade %d filled for %d lamports, replay gives %d", trade.Seq, trade.SolAmount, fill.SolAmount)
+		}
+
+		if trade.Type == SideBuy {
+			positions[trade.Wallet] += trade.Amount
+		} else {
+			positions[trade.Wallet] -= trade.Amount
+		}
+
+		if trade.Venue != VenuePool && ShouldGraduate(coin, curve) {
+			coin.Graduated = true
+			seeded, _ := newPool(coin, trade.Timestamp)
+			if seeded.LPSupply > 0 {
+				if pool != nil {
+					seeded.FeeBps = pool.FeeBps
+				}
+				r.Pool = seeded
+			}
+		}
+	}
+	if !applyLiquidity(math.MaxInt64) {
+		return r, nil
+	}
+
+	for _, amount := range positions {
+		if amount > 0 {
+			coin.Holders++
+		}
+	}
+	return r, nil
+}
+
+func (r *Replay) problem(format string, args ...interface{}) {
+	r.Problems = append(r.Problems, fmt.Sprintf(format, args...))
+}
+
+// Diverged lists the stored coin and pool fields that differ from the replay
+func (r *Replay) Diverged(coin *models.Coin, pool *models.Pool) []Divergence {
+	var diffs []Divergence
+	check := func(field string, stored, derived interface{}) {
+		if stored != derived {
+			diffs = append(diffs, Divergence{Field: field, Stored: fmt.Sprint(stored), Derived: fmt.Sprint(derived)})
+		}
+	}
+
+	derived := &r.Coin
+	check("totalSupply", coin.TotalSupply, derived.TotalSupply)
+	check("price", coin.Price, derived.Price)
+	check("marketCap", coin.MarketCap, derived.MarketCap)
+	check("progress", coin.Progress, derived.Progress)
+	check("realSolReserves", coin.RealSolReserves, derived.RealSolReserves)
+	check("realTokenReserves", coin.RealTokenReserves, derived.RealTokenReserves)
+	check("virtualSolReserves", coin.VirtualSolReserves, derived.VirtualSolReserves)
+	check("virtualTokenReserves", coin.VirtualTokenReserves, derived.VirtualTokenReserves)
+	check("holders", coin.Holders, derived.Holders)
+	check("tradeSeq", coin.TradeSeq, derived.TradeSeq)
+	check("liquiditySeq", coin.LiquiditySeq, derived.LiquiditySeq)
+	check("graduated", coin.Graduated, derived.Graduated)
+
+	if pool != nil && r.Pool != nil {
+		check("pool.solReserve", pool.SolReserve, r.Pool.SolReserve)
+		check("pool.tokenReserve", pool.TokenReserve, r.Pool.TokenReserve)
+		check("pool.lpSupply", pool.LPSupply, r.Pool.LPSupply)
+	} else if (pool != nil) != (r.Pool != nil) {
+		check("pool", pool != nil, r.Pool != nil)
+	}
+	return diffs
+}
+
+// Verification is the outcome of checking one coin against its log
+type Verification struct {
+	CoinID      string       `json:"coinId"`
+	Trades      int          `json:"trades"`
+	Divergences []Divergence `json:"divergences,omitempty"`
+	Problems    []string     `json:"problems,omitempty"`
+	Repaired    bool         `json:"repaired"`
+}
+
+// VerifyCoin replays a coin's log under its row lock and compares the result with
+// the stored coin and pool. With repair, diverged state is overwritten with the
+// replay, unless the log itself could not be replayed or a divergence is one
+// repair cannot fix: graduation, which also moved liquidity, or a pool whose LP
+// positions predate the liquidity log. The caller owns tx.
+func VerifyCoin(tx *gorm.DB, coinID string, repair bool) (*Verification, error) {
+	var coin models.Coin
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&coin, "id = ?", coinID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrCoinNotFound
+		}
+		return nil, fmt.Errorf("%w: %v", ErrStorage, err)
+	}
+	var pool *models.Pool
+	if coin.Graduated {
+		var err error
+		if pool, err = lockPool(tx, coinID); err != nil && !errors.Is(err, ErrPoolNotFound) {
+			return nil, err
+		}
+	}
+
+	var trades []models.Trade
+	if err := tx.Where("coin_id = ?", coinID).Order("seq").Find(&trades).Error; err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrStorage, err)
+	}
+	var events []models.LiquidityEvent
+	if err := tx.Where("coin_id = ?", coinID).Order("seq").Find(&events).Error; err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrStorage, err)
+	}
+
+	replay, err := ReplayCoin(&coin, pool, trades, events)
+	if err != nil {
+		return nil, err
+	}
+	v := &Verification{CoinID: coinID, Trades: len(trades), Problems: replay.Problems}
+	v.Divergences = replay.Diverged(&coin, pool)
+
+	if coin.Graduated != replay.Coin.Graduated {
+		v.Problems = append(v.Problems, fmt.Sprintf("stored graduated is %v, the log says %v", coin.Graduated, replay.Coin.Graduated))
+	}
+	if pool != nil && replay.Pool != nil {
+		var shares int64
+		if err := tx.Model(&models.LPPosition{}).Where("coin_id = ?", coinID).
+			Select("COALESCE(SUM(shares), 0)").Scan(&shares).Error; err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrStorage, err)
+		}
+		if shares != replay.Pool.LPSupply {
+			v.Problems = append(v.Problems, fmt.Sprintf("LP positions hold %d shares, the log accounts for %d", shares, replay.Pool.LPSupply))
+		}
+	}
+
+	if !repair || len(v.Divergences) == 0 || len(v.Problems) > 0 {
+		return v, nil
+	}
+
+	err = tx.Model(&coin).Select(
+		"total_supply", "price", "market_cap", "progress", "real_sol_reserves", "real_token_reserves",
+		"virtual_sol_reserves", "virtual_token_reserves", "holders", "trade_seq", "liquidity_seq",
+	).Updates(&replay.Coin).Error
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrStorage, err)
+	}
+	if pool != nil {
+		err := tx.Model(pool).Select("sol_reserve", "token_reserve", "lp_supply").Updates(replay.Pool).Error
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrStorage, err)
+		}
+	}
+	v.Repaired = true
+	return v, nil
+}
@@ -0,0 +1,62 @@
+package trading
+
+import (
+	"testing"
+	"time"
+
+	"memepump/blockchain"
+	"memepump/models"
+	"memepump/units"
+)
+
+func TestReplayMatchesLiveState(t *testing.T) {
+	curve := blockchain.DefaultCurve()
+	var live models.Coin
+	InitCoin(&live, curve)
+
+	// Trade the live coin the way Execute does, logging each fill
+	var trades []models.Trade
+	trade := func(wallet, side string, amount units.Tokens) {
+		fill, err := Settle(&live, curve, side, amount)
+		if err != nil {
+			t.Fatalf("%s %d: %v", side, amount, err)
+		}
+		live.TradeSeq++
+		trades = append(trades, models.Trade{
+			Seq: live.TradeSeq, Type: side, Venue: VenueCurve, Wallet: wallet,
+			Amount: fill.Amount, SolAmount: fill.SolAmount, Timestamp: time.Now(),
+		})
+	}
+	trade("alice", SideBuy, 5000000*units.TokenUnit)
+	trade("bob", SideBuy, 2000000*units.TokenUnit)
+	trade("alice", SideSell, 5000000*units.TokenUnit)
+	live.Holders = 1
+
+	replay, err := ReplayCoin(&live, nil, trades, nil)
+	if err != nil {
+		t.Fatalf("ReplayCoin: %v", err)
+	}
+	if len(replay.Problems) > 0 {
+		t.Fatalf("replay problems: %v", replay.Problems)
+	}
+	if diffs := replay.Diverged(&live, nil); len(diffs) > 0 {
+		t.Errorf("replay diverges from live state: %+v", diffs)
+	}
+
+	// Drifted columns are reported against the log
+	drifted := live
+	drifted.Holders = 7
+	drifted.TotalSupply += units.TokenUnit
+	if diffs := replay.Diverged(&drifted, nil); len(diffs) != 2 {
+		t.Errorf("divergences = %+v; want holders and totalSupply", diffs)
+	}
+
+	// A missing trade breaks the log
+	replay, err = ReplayCoin(&live, nil, trades[1:], nil)
+	if err != nil {
+		t.Fatalf("ReplayCoin: %v", err)
+	}
+	if len(replay.Problems) == 0 {
+		t.Error("replay of a log with a gap reported no problems")
+	}
+}
@@ -0,0 +1,173 @@
+package trading
+
+import (
+	"errors"
+	"fmt"
+
+	"memepump/models"
+	"memepump/units"
+
+	"gorm.io/gorm"
+)
+
+// DefaultRuleSlippageBps bounds position rule sells, overridable through RULE_SLIPPAGE_BPS
+const DefaultRuleSlippageBps = 500
+
+// RuleSlippageBps is the slippage bound of rules that do not set their own
+var RuleSlippageBps int64 = DefaultRuleSlippageBps
+
+// Position rule errors
+var (
+	ErrRuleSide        = errors.New("position rules can only sell")
+	ErrRuleTrigger     = errors.New("set either triggerPrice or a triggerPercent between 0 and 100")
+	ErrRuleWouldFire   = errors.New("rule would trigger at the current price")
+	ErrNoEntryPrice    = errors.New("position has no average entry price")
+	ErrInvalidSlippage = errors.New("slippageBps must be between 0 and 10000")
+)
+
+// placeRule validates a stop-loss, take-profit or trailing stop on the wallet's
+// position and fixes its trigger price. Rules reserve nothing; they sell from
+// the position as it stands when they trigger.
+func placeRule(tx *gorm.DB, order *models.Order, coin *models.Coin) error {
+	if order.Side == "" {
+		order.Side = SideSell
+	}
+	if order.Side != SideSell {
+		return ErrRuleSide
+	}
+	if order.SolAmount != 0 || order.Amount < 0 {
+		return ErrInvalidAmount
+	}
+	if order.SlippageBps == 0 {
+		order.SlippageBps = RuleSlippageBps
+	}
+	if order.SlippageBps < 0 || order.SlippageBps > BpsDenominator {
+		return ErrInvalidSlippage
+	}
+
+	if err := checkWalletLinked(tx, order.UserID, order.Wallet); err != nil {
+		return err
+	}
+	balance, err := lockBalance(tx, order.Wallet, order.CoinID)
+	if err != nil {
+		return err
+	}
+	if balance.UserID != order.UserID {
+		if balance.UserID == "" {
+			return ErrInsufficientBalance
+		}
+		return ErrWalletNotOwned
+	}
+	if balance.Amount <= 0 || order.Amount > balance.Amount {
+		return ErrInsufficientBalance
+	}
+
+	entry := units.PriceOf(balance.CostBasis, balance.Amount)
+	return setRuleTrigger(order, entry, coin.Price)
+}
+
+// setRuleTrigger derives a rule's trigger price from an absolute price, a percent
+// from the average entry price or, for trailing stops, a trail below the spot price
+func setRuleTrigger(order *models.Order, entry, spot units.Price) error {
+	pct := order.TriggerPercent
+	switch {
+	case order.Type == OrderTrailingStop:
+		if order.TriggerPrice != 0 || pct <= 0 || pct >= 100 {
+			return ErrRuleTrigger
+		}
+		order.PeakPrice = spot
+		order.TriggerPrice = trailPrice(spot, pct)
+	case pct == 0:
+		if order.TriggerPrice <= 0 {
+			return ErrRuleTrigger
+		}
+	case order.TriggerPrice != 0 || pct < 0 || (pct >= 100 && order.Type == OrderStopLoss):
+		return ErrRuleTrigger
+	case entry <= 0:
+		return ErrNoEntryPrice
+	case order.Type == OrderStopLoss:
+		order.TriggerPrice = units.Price(float64(entry) * (1 - pct/100))
+	default:
+		order.TriggerPrice = units.Price(float64(entry) * (1 + pct/100))
+	}
+
+	if order.TriggerPrice <= 0 {
+		return ErrRuleTrigger
+	}
+	if OrderTriggered(order, spot) {
+		return ErrRuleWouldFire
+	}
+	return nil
+}
+
+// trailPrice is the stop price pct percent below peak
+func trailPrice(peak units.Price, pct float64) units.Price {
+	return units.Price(float64(peak) * (1 - pct/100))
+}
+
+// trailStops raises the peak and stop price of the coin's trailing stops to a new high
+func trailStops(tx *gorm.DB, coin *models.Coin) error {
+	var stops []models.Order
+	if err := tx.Where("coin_id = ? AND status = ? AND type = ? AND peak_price < ?",
+		coin.ID, OrderOpen, OrderTrailingStop, coin.Price).Find(&stops).Error; err != nil {
+		return fmt.Errorf("%w: %v", ErrStorage, err)
+	}
+	for i := range stops {
+		stop := &stops[i]
+		if err := tx.Model(stop).Where("status = ?", OrderOpen).Updates(map[string]interface{}{
+			"peak_price":    coin.Price,
+			"trigger_price": trailPrice(coin.Price, stop.TriggerPercent),
+		}).Error; err != nil {
+			return fmt.Errorf("%w: %v", ErrStorage, err)
+		}
+	}
+	return nil
+}
+
+// sizeRuleSell sells the rule's amount, or the whole position, capped at what the
+// wallet holds unreserved, and bounds the return by the rule's slippage from its trigger
+func sizeRuleSell(tx *gorm.DB, order *models.Order, coin *models.Coin, req *models.TradeRequest) error {
+	balance, err := lockBalance(tx, order.Wallet, order.CoinID)
+	if err != nil {
+		return err
+	}
+	available := balance.Amount - balance.Reserved
+	amount := order.Amount
+	if amount == 0 || amount > available {
+		amount = available
+	}
+	if amount <= 0 {
+		return ErrInsufficientBalance
+	}
+
+	var pool *models.Pool
+	if coin.Graduated {
+		if pool, err = lockPool(tx, coin.ID); err != nil {
+			return err
+		}
+	}
+	req.Amount = amount
+	req.MinReturn = ruleMinReturn(order, pool, amount)
+	return nil
+}
+
+// ruleMinReturn bounds a rule's sell by what amount is worth at the rule's own
+// reference price, after fees, less the rule's slippage. The reference is the
+// trigger of a stop-loss or take-profit and the stop trailing the peak of a
+// trailing stop, so a fill gapping past it by more than the slippage fails.
+func ruleMinReturn(order *models.Order, pool *models.Pool, amount units.Tokens) units.Lamports {
+	reference := order.TriggerPrice
+	if order.Type == OrderTrailingStop {
+		reference = trailPrice(order.PeakPrice, order.TriggerPercent)
+	}
+	sol := units.Value(amount, reference)
+	if pool != nil {
+		sol = units.Lamports(units.MulDiv(int64(sol), BpsDenominator-pool.FeeBps, BpsDenominator))
+	}
+	platform, creator := Fees.Charge(sol)
+	net := sol - platform - creator
+	if net <= 0 {
+		return 0
+	}
+	return units.Lamports(units.MulDiv(int64(net), BpsDenominator-order.SlippageBps, BpsDenominator))
+}
@@ -0,0 +1,94 @@
+package trading
+
+import (
+	"errors"
+
+	"memepump/blockchain"
+	"memepump/models"
+	"memepump/units"
+)
+
+// Trade sides
+const (
+	SideBuy  = "buy"
+	SideSell = "sell"
+)
+
+// Trade venues
+const (
+	VenueCurve = "curve"
+	VenuePool  = "pool"
+)
+
+// Settlement errors
+var (
+	ErrInvalidSide        = errors.New("type must be buy or sell")
+	ErrInvalidAmount      = errors.New("amount must be positive")
+	ErrInsufficientSupply = errors.New("insufficient supply")
+	ErrExceedsMaxSupply   = errors.New("amount exceeds remaining curve supply")
+)
+
+// Fill is the result of settling a trade against a coin's curve
+type Fill struct {
+	Side         string         `json:"side"`
+	Amount       units.Tokens   `json:"amount"`
+	SolAmount    units.Lamports `json:"solAmount"` // Curve cost (buy) or return (sell), before fees
+	PlatformFee  units.Lamports `json:"platformFee"`
+	CreatorFee   units.Lamports `json:"creatorFee"`
+	Total        units.Lamports `json:"total"` // What the trader pays (buy) or receives (sell)
+	AvgPrice     units.Price    `json:"avgPrice"`
+	SupplyBefore units.Tokens   `json:"supplyBefore"`
+	SupplyAfter  units.Tokens   `json:"supplyAfter"`
+	PriceBefore  units.Price    `json:"priceBefore"`
+	PriceAfter   units.Price    `json:"priceAfter"`
+}
+
+// Settle prices a trade on the coin's curve, charges Fees and applies it to the coin
+func Settle(coin *models.Coin, curve *blockchain.BondingCurve, side string, amount units.Tokens) (*Fill, error) {
+	if amount <= 0 {
+		return nil, ErrInvalidAmount
+	}
+
+	fill := &Fill{
+		Side:         side,
+		Amount:       amount,
+		SupplyBefore: coin.TotalSupply,
+		PriceBefore:  curve.CalculatePrice(coin.TotalSupply),
+	}
+
+	switch side {
+	case SideBuy:
+		if amount > curve.MaxSupply-coin.TotalSupply {
+			return nil, ErrExceedsMaxSupply
+		}
+		fill.SolAmount = curve.CalculateBuyPrice(coin.TotalSupply, amount)
+		if fill.SolAmount == units.MaxLamports {
+			return nil, ErrExceedsMaxSupply
+		}
+		fill.SupplyAfter = coin.TotalSupply + amount
+	case SideSell:
+		if amount > coin.TotalSupply {
+			return nil, ErrInsufficientSupply
+		}
+		fill.SolAmount = curve.CalculateSellReturn(coin.TotalSupply, amount)
+		fill.SupplyAfter = coin.TotalSupply - amount
+	default:
+		return nil, ErrInvalidSide
+	}
+
+	fill.AvgPrice = units.PriceOf(fill.SolAmount, amount)
+	fill.PriceAfter = curve.CalculatePrice(fill.SupplyAfter)
+	if err := chargeFees(fill, Fees); err != nil {
+		return nil, err
+	}
+
+	coin.TotalSupply = fill.SupplyAfter
+	if side == SideBuy {
+		coin.RealSolReserves += fill.SolAmount
+	} else {
+		coin.RealSolReserves -= fill.SolAmount
+	}
+	refreshCoin(coin, curve)
+
+	return fill, nil
+}
@@ -0,0 +1,310 @@
+package trading
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"memepump/blockchain"
+	"memepump/models"
+	"memepump/units"
+)
+
+func TestCalculateProgress(t *testing.T) {
+	curve := blockchain.DefaultCurve()
+
+	tests := []struct {
+		marketCap units.Lamports
+		expected  float64
+	}{
+		{0, 0},
+		{50000 * units.LamportsPerSol, 50},
+		{100000 * units.LamportsPerSol, 100},
+		{200000 * units.LamportsPerSol, 100}, // Capped at 100
+	}
+
+	for _, test := range tests {
+		progress := curve.CalculateProgress(0, test.marketCap)
+		if progress != test.expected {
+			t.Errorf("CalculateProgress(%d) = %f; want %f", test.marketCap, progress, test.expected)
+		}
+	}
+}
+
+func TestSettleUsesCoinCurve(t *testing.T) {
+	basePrice := units.PriceFromSol(0.001)
+	curve, err := NewCurve(&models.CurveParams{Type: "linear", BasePrice: basePrice, Slope: 0.000001})
+	if err != nil {
+		t.Fatalf("NewCurve: %v", err)
+	}
+
+	var coin models.Coin
+	InitCoin(&coin, curve)
+	if coin.Price != basePrice || coin.TotalSupply != 0 {
+		t.Fatalf("launch state = price %d supply %d; want %d, 0", coin.Price, coin.TotalSupply, basePrice)
+	}
+
+	amount := units.Tokens(1000 * units.TokenUnit)
+	buy, err := Settle(&coin, curve, SideBuy, amount)
+	if err != nil {
+		t.Fatalf("buy: %v", err)
+	}
+	if buy.SolAmount <= units.LamportsPerSol || buy.PriceAfter <= buy.PriceBefore {
+		t.Errorf("buy fill = %+v; want cost above base and rising price", buy)
+	}
+	if coin.TotalSupply != amount || coin.Price != buy.PriceAfter {
+		t.Errorf("coin after buy = supply %d price %d", coin.TotalSupply, coin.Price)
+	}
+
+	sell, err := Settle(&coin, curve, SideSell, amount)
+	if err != nil {
+		t.Fatalf("sell: %v", err)
+	}
+	if sell.SolAmount > buy.SolAmount || buy.SolAmount-sell.SolAmount > 1 {
+		t.Errorf("sell return %d; want within a lamport below buy cost %d", sell.SolAmount, buy.SolAmount)
+	}
+	if coin.TotalSupply != 0 {
+		t.Errorf("supply after round trip = %d; want 0", coin.TotalSupply)
+	}
+}
+
+func TestSettleRejectsInvalidTrades(t *testing.T) {
+	curve := blockchain.DefaultCurve()
+	var coin models.Coin
+	InitCoin(&coin, curve)
+
+	tests := []struct {
+		side   string
+		amount units.Tokens
+		err    error
+	}{
+		{SideSell, 1, ErrInsufficientSupply},
+		{SideBuy, curve.MaxSupply + 1, ErrExceedsMaxSupply},
+		{SideBuy, 0, ErrInvalidAmount},
+		{"hold", 1, ErrInvalidSide},
+	}
+
+	for _, test := range tests {
+		if _, err := Settle(&coin, curve, test.side, test.amount); err != test.err {
+			t.Errorf("Settle(%s, %d) error = %v; want %v", test.side, test.amount, err, test.err)
+		}
+	}
+}
+
+func TestNewCurveValidation(t *testing.T) {
+	if _, err := NewCurve(nil); err != nil {
+		t.Errorf("default curve rejected: %v", err)
+	}
+	if _, err := NewCurve(&models.CurveParams{Type: "quadratic"}); err == nil {
+		t.Error("unknown curve type accepted")
+	}
+	if _, err := NewCurve(&models.CurveParams{Type: "exponential", K: 0.001}); err == nil {
+		t.Error("overflowing exponential curve accepted")
+	}
+}
+
+func TestCoinKeepsCurveParams(t *testing.T) {
+	curve, err := NewCurve(&models.CurveParams{
+		Type:   "logarithmic",
+		Params: json.RawMessage(`{"scale":0.0001,"offset":"10000000000000"}`),
+	})
+	if err != nil {
+		t.Fatalf("NewCurve: %v", err)
+	}
+
+	var coin models.Coin
+	InitCoin(&coin, curve)
+	coin.TotalSupply = units.Tokens(300000000 * units.TokenUnit)
+
+	stored, err := CurveForCoin(&coin)
+	if err != nil {
+		t.Fatalf("CurveForCoin: %v", err)
+	}
+	if got, want := stored.CalculatePrice(coin.TotalSupply), curve.CalculatePrice(coin.TotalSupply); got != want {
+		t.Errorf("stored curve price = %d; want %d", got, want)
+	}
+}
+
+func TestVirtualAMMGraduatesWhenRealReservesRunOut(t *testing.T) {
+	withFees(t, FeeSchedule{})
+
+	curve, err := NewCurve(&models.CurveParams{Type: "virtual_amm"})
+	if err != nil {
+		t.Fatalf("NewCurve: %v", err)
+	}
+	var coin models.Coin
+	InitCoin(&coin, curve)
+
+	// 1 SOL at launch buys y * 1 / (x + 1) = 1.073B / 31 tokens
+	quote, err := NewSolQuote(coin, curve, units.LamportsPerSol)
+	if err != nil {
+		t.Fatalf("NewSolQuote: %v", err)
+	}
+	want := units.TokensFromFloat(1073000000.0 / 31)
+	if diff := quote.Amount - want; diff > units.TokenUnit || diff < -units.TokenUnit {
+		t.Errorf("1 SOL buys %d; want about %d", quote.Amount, want)
+	}
+
+	if _, err := Settle(&coin, curve, SideBuy, coin.RealTokenReserves-1); err != nil {
+		t.Fatalf("buy: %v", err)
+	}
+	if ShouldGraduate(&coin, curve) {
+		t.Error("graduated with real tokens left")
+	}
+	if _, err := Settle(&coin, curve, SideBuy, coin.RealTokenReserves); err != nil {
+		t.Fatalf("buy: %v", err)
+	}
+	if !ShouldGraduate(&coin, curve) || coin.Progress != 100 {
+		t.Errorf("sold out curve: graduate %v, progress %f; want true, 100", ShouldGraduate(&coin, curve), coin.Progress)
+	}
+
+	// Selling out the 793.1M real tokens takes k / 279.9M - 30 = ~85 SOL
+	if sol := coin.RealSolReserves.SOL(); sol < 84.9 || sol > 85.1 {
+		t.Errorf("real SOL reserves = %f; want about 85", sol)
+	}
+	if coin.VirtualTokenReserves != units.Tokens(279900000*units.TokenUnit) {
+		t.Errorf("virtual token reserves = %d; want 279.9M tokens", coin.VirtualTokenReserves)
+	}
+}
+
+func TestFeesAreChargedOnBothSides(t *testing.T) {
+	withFees(t, FeeSchedule{PlatformBps: 100, CreatorBps: 50})
+
+	curve := blockchain.DefaultCurve()
+	var coin models.Coin
+	InitCoin(&coin, curve)
+
+	buy, err := Settle(&coin, curve, SideBuy, 5000000*units.TokenUnit)
+	if err != nil {
+		t.Fatalf("buy: %v", err)
+	}
+	if want := units.Lamports(units.MulDivCeil(int64(buy.SolAmount), 100, BpsDenominator)); buy.PlatformFee != want {
+		t.Errorf("platform fee = %d; want %d", buy.PlatformFee, want)
+	}
+	if buy.Total != buy.SolAmount+buy.PlatformFee+buy.CreatorFee {
+		t.Errorf("buy total = %d; want cost plus fees", buy.Total)
+	}
+
+	sell, err := Settle(&coin, curve, SideSell, buy.Amount)
+	if err != nil {
+		t.Fatalf("sell: %v", err)
+	}
+	if sell.Total != sell.SolAmount-sell.PlatformFee-sell.CreatorFee {
+		t.Errorf("sell total = %d; want return minus fees", sell.Total)
+	}
+	if coin.RealSolReserves < 0 {
+		t.Errorf("round trip left real SOL reserves at %d", coin.RealSolReserves)
+	}
+
+	// SOL-in buys spend at most the budget, fees included
+	for _, budget := range []units.Lamports{1, 999, units.LamportsPerSol, 1234567891011} {
+		tokens := TokensForBudget(&coin, curve, budget, Fees)
+		cost := curve.CalculateBuyPrice(coin.TotalSupply, tokens)
+		platform, creator := Fees.Charge(cost)
+		if cost+platform+creator > budget {
+			t.Errorf("budget %d bought %d tokens costing %d with fees", budget, tokens, cost+platform+creator)
+		}
+	}
+}
+
+func TestDustSellIsRejected(t *testing.T) {
+	withFees(t, FeeSchedule{PlatformBps: 100, CreatorBps: 50})
+
+	curve := blockchain.DefaultCurve()
+	var coin models.Coin
+	InitCoin(&coin, curve)
+	if _, err := Settle(&coin, curve, SideBuy, 1000000*units.TokenUnit); err != nil {
+		t.Fatalf("buy: %v", err)
+	}
+
+	// Sell the fewest tokens that return a single lamport: both fees round up to 1
+	var amount units.Tokens
+	for amount = 1; curve.CalculateSellReturn(coin.TotalSupply, amount) < 1; amount++ {
+	}
+	if sol := curve.CalculateSellReturn(coin.TotalSupply, amount); sol != 1 {
+		t.Fatalf("smallest sell returns %d lamports; want 1", sol)
+	}
+
+	supply, reserves := coin.TotalSupply, coin.RealSolReserves
+	if _, err := Settle(&coin, curve, SideSell, amount); !errors.Is(err, ErrInvalidAmount) {
+		t.Errorf("1-lamport sell: err = %v; want ErrInvalidAmount", err)
+	}
+	if coin.TotalSupply != supply || coin.RealSolReserves != reserves {
+		t.Error("rejected sell changed the coin")
+	}
+
+	pool := &models.Pool{SolReserve: units.LamportsPerSol, TokenReserve: 1000000 * units.TokenUnit, FeeBps: 25}
+	poolBefore := *pool
+	if _, err := Swap(&coin, pool, SideSell, 1); !errors.Is(err, ErrInvalidAmount) {
+		t.Errorf("dust pool sell: err = %v; want ErrInvalidAmount", err)
+	}
+	if *pool != poolBefore {
+		t.Error("rejected pool sell changed the pool")
+	}
+}
+
+func TestPoolSeedOpensAtFinalPrice(t *testing.T) {
+	withFees(t, FeeSchedule{})
+
+	curve, err := NewCurve(&models.CurveParams{Type: "virtual_amm"})
+	if err != nil {
+		t.Fatalf("NewCurve: %v", err)
+	}
+	var coin models.Coin
+	InitCoin(&coin, curve)
+	if _, err := Settle(&coin, curve, SideBuy, coin.RealTokenReserves); err != nil {
+		t.Fatalf("buy: %v", err)
+	}
+
+	sol, tokens := PoolSeed(&coin)
+	if sol != coin.RealSolReserves {
+		t.Errorf("pool SOL = %d; want the %d raised on the curve", sol, coin.RealSolReserves)
+	}
+	if price := units.PriceOf(sol, tokens); price < coin.Price-1 || price > coin.Price+1 {
+		t.Errorf("pool opens at %d; want the final curve price %d", price, coin.Price)
+	}
+}
+
+func TestFeeScheduleValidation(t *testing.T) {
+	if err := (FeeSchedule{PlatformBps: 100, CreatorBps: 50}).Validate(); err != nil {
+		t.Errorf("default fees rejected: %v", err)
+	}
+	if err := (FeeSchedule{PlatformBps: -1}).Validate(); err == nil {
+		t.Error("negative fee accepted")
+	}
+	if err := (FeeSchedule{PlatformBps: 800, CreatorBps: 201}).Validate(); err == nil {
+		t.Error("fees above MaxFeeBps accepted")
+	}
+}
+
+// withFees swaps the fee schedule for the duration of a test
+func withFees(t *testing.T, fees FeeSchedule) {
+	previous := Fees
+	Fees = fees
+	t.Cleanup(func() { Fees = previous })
+}
+
+func TestQuoteDoesNotMutateCoin(t *testing.T) {
+	curve := blockchain.DefaultCurve()
+	var coin models.Coin
+	InitCoin(&coin, curve)
+
+	quote, err := NewQuote(coin, curve, SideBuy, 1000000*units.TokenUnit)
+	if err != nil {
+		t.Fatalf("NewQuote: %v", err)
+	}
+	if coin.TotalSupply != 0 {
+		t.Errorf("quote mutated coin supply to %d", coin.TotalSupply)
+	}
+	if quote.PriceAfter <= quote.PriceBefore || quote.PriceImpact <= 0 {
+		t.Errorf("quote = %+v; want rising price and positive impact", quote)
+	}
+
+	solQuote, err := NewSolQuote(coin, curve, quote.Total)
+	if err != nil {
+		t.Fatalf("NewSolQuote: %v", err)
+	}
+	if diff := solQuote.Amount - quote.Amount; diff > units.TokenUnit || diff < -units.TokenUnit {
+		t.Errorf("SOL-in quote bought %d token units; want about %d", solQuote.Amount, quote.Amount)
+	}
+}
@@ -0,0 +1,208 @@
+package units
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"math/big"
+	"strconv"
+	"strings"
+)
+
+// Decimals and scales for on-chain base units
+const (
+	TokenDecimals = 6 // pump.fun style SPL tokens
+	SolDecimals   = 9
+
+	TokenUnit      = 1000000    // Base units per whole token
+	LamportsPerSol = 1000000000 // Lamports per SOL
+	PriceScale     = 1000000    // Micro-lamports per lamport
+)
+
+// Tokens is a token amount in base units (TokenDecimals)
+type Tokens int64
+
+// Lamports is a SOL amount in lamports
+type Lamports int64
+
+// Price is a token price in micro-lamports per whole token
+type Price int64
+
+// MaxLamports is returned when a cost does not fit in a Lamports value
+const MaxLamports = Lamports(math.MaxInt64)
+
+// ========================================
+// Float conversions (display and curve math only)
+// ========================================
+
+// TokensFromFloat converts whole tokens to base units, rounding down
+func TokensFromFloat(whole float64) Tokens {
+	return Tokens(floorInt64(whole * TokenUnit))
+}
+
+// Float returns the amount in whole tokens
+func (t Tokens) Float() float64 {
+	return float64(t) / TokenUnit
+}
+
+// LamportsFromSol converts SOL to lamports, rounding to the nearest lamport
+func LamportsFromSol(sol float64) Lamports {
+	return Lamports(clampInt64(math.Round(sol * LamportsPerSol)))
+}
+
+// LamportsCeil converts SOL to lamports, rounding up (amounts owed to the platform)
+func LamportsCeil(sol float64) Lamports {
+	return Lamports(clampInt64(math.Ceil(sol * LamportsPerSol)))
+}
+
+// LamportsFloor converts SOL to lamports, rounding down (amounts paid out)
+func LamportsFloor(sol float64) Lamports {
+	return Lamports(floorInt64(sol * LamportsPerSol))
+}
+
+// SOL returns the amount in SOL
+func (l Lamports) SOL() float64 {
+	return float64(l) / LamportsPerSol
+}
+
+// PriceFromSol converts a price in SOL per token to micro-lamports per token
+func PriceFromSol(solPerToken float64) Price {
+	return Price(clampInt64(math.Round(solPerToken * LamportsPerSol * PriceScale)))
+}
+
+// SOL returns the price in SOL per whole token
+func (p Price) SOL() float64 {
+	return float64(p) / (LamportsPerSol * PriceScale)
+}
+
+func floorInt64(f float64) int64 {
+	return clampInt64(math.Floor(f))
+}
+
+func clampInt64(f float64) int64 {
+	switch {
+	case math.IsNaN(f) || f <= 0:
+		return 0
+	case f >= math.MaxInt64:
+		return math.MaxInt64
+	}
+	return int64(f)
+}
+
+// ========================================
+// Exact integer arithmetic
+// ========================================
+
+// Value returns what amount is worth at price, rounded down to the lamport
+func Value(amount Tokens, price Price) Lamports {
+	v := new(big.Int).Mul(big.NewInt(int64(amount)), big.NewInt(int64(price)))
+	v.Quo(v, big.NewInt(TokenUnit*PriceScale))
+	if !v.IsInt64() {
+		return MaxLamports
+	}
+	return Lamports(v.Int64())
+}
+
+// PriceOf returns the average price of buying amount for cost
+func PriceOf(cost Lamports, amount Tokens) Price {
+	if amount <= 0 {
+		return 0
+	}
+	v := new(big.Int).Mul(big.NewInt(int64(cost)), big.NewInt(TokenUnit*PriceScale))
+	v.Quo(v, big.NewInt(int64(amount)))
+	if !v.IsInt64() {
+		return Price(math.MaxInt64)
+	}
+	return Price(v.Int64())
+}
+
+// MulDiv returns a * num / den without intermediate overflow, rounding down
+func MulDiv(a, num, den int64) int64 {
+	if den == 0 {
+		return 0
+	}
+	v := new(big.Int).Mul(big.NewInt(a), big.NewInt(num))
+	v.Quo(v, big.NewInt(den))
+	if !v.IsInt64() {
+		return math.MaxInt64
+	}
+	return v.Int64()
+}
+
+// MulDivCeil returns a * num / den without intermediate overflow, rounding up
+func MulDivCeil(a, num, den int64) int64 {
+	if den == 0 {
+		return 0
+	}
+	v, rem := new(big.Int).QuoRem(new(big.Int).Mul(big.NewInt(a), big.NewInt(num)), big.NewInt(den), new(big.Int))
+	if rem.Sign() > 0 {
+		v.Add(v, big.NewInt(1))
+	}
+	if !v.IsInt64() {
+		return math.MaxInt64
+	}
+	return v.Int64()
+}
+
+// Rescale converts an on-chain amount with the given decimals to Tokens
+func Rescale(amount uint64, decimals uint8) Tokens {
+	v := new(big.Int).SetUint64(amount)
+	switch {
+	case decimals > TokenDecimals:
+		v.Quo(v, new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(decimals-TokenDecimals)), nil))
+	case decimals < TokenDecimals:
+		v.Mul(v, new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(TokenDecimals-decimals)), nil))
+	}
+	if !v.IsInt64() {
+		return Tokens(math.MaxInt64)
+	}
+	return Tokens(v.Int64())
+}
+
+// ========================================
+// JSON: base units as strings so JavaScript clients never lose precision
+// ========================================
+
+func (t Tokens) MarshalJSON() ([]byte, error)   { return marshalInt(int64(t)) }
+func (l Lamports) MarshalJSON() ([]byte, error) { return marshalInt(int64(l)) }
+func (p Price) MarshalJSON() ([]byte, error)    { return marshalInt(int64(p)) }
+
+func (t *Tokens) UnmarshalJSON(data []byte) error {
+	return unmarshalInt(data, (*int64)(t))
+}
+
+func (l *Lamports) UnmarshalJSON(data []byte) error {
+	return unmarshalInt(data, (*int64)(l))
+}
+
+func (p *Price) UnmarshalJSON(data []byte) error {
+	return unmarshalInt(data, (*int64)(p))
+}
+
+func marshalInt(v int64) ([]byte, error) {
+	return json.Marshal(strconv.FormatInt(v, 10))
+}
+
+// unmarshalInt accepts "123" and, for hand-written requests, a bare integer 123
+func unmarshalInt(data []byte, target *int64) error {
+	s := string(data)
+	if s == "null" {
+		return nil
+	}
+	s = strings.Trim(s, `"`)
+	v, err := ParseInt(s)
+	if err != nil {
+		return err
+	}
+	*target = v
+	return nil
+}
+
+// ParseInt parses a base-unit amount such as a query parameter
+func ParseInt(s string) (int64, error) {
+	v, err := strconv.ParseInt(s, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid base-unit amount %q", s)
+	}
+	return v, nil
+}
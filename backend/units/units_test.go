@@ -0,0 +1,68 @@
+package units
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestJSONUsesStrings(t *testing.T) {
+	type payload struct {
+		Amount Tokens   `json:"amount"`
+		Cost   Lamports `json:"cost"`
+		Price  Price    `json:"price"`
+	}
+
+	data, err := json.Marshal(payload{Amount: 1500000, Cost: 9007199254740993, Price: 42})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := `{"amount":"1500000","cost":"9007199254740993","price":"42"}`; string(data) != want {
+		t.Errorf("Marshal = %s; want %s", data, want)
+	}
+
+	var decoded payload
+	if err := json.Unmarshal([]byte(`{"amount":"1500000","cost":9007199254740993,"price":null}`), &decoded); err != nil {
+		t.Fatal(err)
+	}
+	if decoded.Amount != 1500000 || decoded.Cost != 9007199254740993 || decoded.Price != 0 {
+		t.Errorf("Unmarshal = %+v", decoded)
+	}
+
+	if err := json.Unmarshal([]byte(`{"amount":"1.5"}`), &decoded); err == nil {
+		t.Error("fractional base units accepted")
+	}
+}
+
+func TestValueAndPriceOf(t *testing.T) {
+	amount := Tokens(2500 * TokenUnit)
+	price := PriceFromSol(0.00001)
+
+	if got := Value(amount, price); got != LamportsFromSol(0.025) {
+		t.Errorf("Value = %d; want %d", got, LamportsFromSol(0.025))
+	}
+	if got := PriceOf(LamportsFromSol(0.025), amount); got != price {
+		t.Errorf("PriceOf = %d; want %d", got, price)
+	}
+	// Products beyond int64 must not wrap around
+	if got := Value(Tokens(1<<62), Price(1<<62)); got != MaxLamports {
+		t.Errorf("overflowing Value = %d; want MaxLamports", got)
+	}
+}
+
+func TestRescale(t *testing.T) {
+	tests := []struct {
+		amount   uint64
+		decimals uint8
+		expected Tokens
+	}{
+		{1000000, 6, 1000000},
+		{1000000000, 9, 1000000},
+		{100, 2, 1000000},
+	}
+
+	for _, test := range tests {
+		if got := Rescale(test.amount, test.decimals); got != test.expected {
+			t.Errorf("Rescale(%d, %d) = %d; want %d", test.amount, test.decimals, got, test.expected)
+		}
+	}
+}
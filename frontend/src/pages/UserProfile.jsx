@@ -37,7 +37,7 @@ const UserProfile = ({ coins }) => {
     }
 
     // Filter created coins
-    const createdCoins = coins.filter(c => c.creator === user.username);
+    const createdCoins = coins.filter(c => c.creatorUserId === user.id);
 
     return (
         <div className="container mx-auto px-4 py-8">